@@ -2,6 +2,6 @@
 echo Building wtop for Windows...
 set GOOS=windows
 set GOARCH=amd64
-go build -o wtop.exe main.go
+go build -o wtop.exe .
 echo Build complete: wtop.exe
 pause
@@ -1,17 +1,20 @@
 #!/bin/bash
 echo "Building wtop for multiple platforms..."
 
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+LDFLAGS="-X main.version=$VERSION"
+
 # Windows
 echo "Building for Windows..."
-GOOS=windows GOARCH=amd64 go build -o wtop-windows.exe .
+GOOS=windows GOARCH=amd64 go build -ldflags "$LDFLAGS" -o wtop-windows.exe .
 
 # Linux
 echo "Building for Linux..."
-GOOS=linux GOARCH=amd64 go build -o wtop-linux .
+GOOS=linux GOARCH=amd64 go build -ldflags "$LDFLAGS" -o wtop-linux .
 
 # macOS
 echo "Building for macOS..."
-GOOS=darwin GOARCH=amd64 go build -o wtop-macos .
+GOOS=darwin GOARCH=amd64 go build -ldflags "$LDFLAGS" -o wtop-macos .
 
 echo "Build complete!"
 ls -la wtop-*
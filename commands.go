@@ -0,0 +1,331 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/SwarnenduG07/wtop/ui"
+)
+
+// command is a wtop subcommand. setup registers the command's flags and
+// returns the function that runs it once they have been parsed; keeping the
+// two apart lets help and completion inspect the flags without running
+// anything.
+type command struct {
+	name    string
+	args    string
+	nargs   int // required positional arguments, or -1 for any number
+	summary string
+	// argFiles and argChoices tell shell completion what the positional
+	// arguments are.
+	argFiles   bool
+	argChoices func() []string
+	setup      func(fs *flag.FlagSet) func(args []string)
+}
+
+// commands is filled in by init because help and completion refer to it.
+var (
+	rootCommand *command
+	commands    []*command
+)
+
+func init() {
+	rootCommand = &command{
+		summary: "wtop is an interactive system monitor for the terminal.",
+		setup:   dashboard,
+	}
+	commands = []*command{
+		{name: "snapshot", summary: "print one snapshot as JSON and exit", setup: snapshotCommand},
+		{name: "batch", summary: "write one ndjson or csv record per interval for scripts", setup: batchCommand},
+		{name: "record", args: "out.wtop", nargs: 1, argFiles: true, summary: "record snapshots to a file for wtop replay", setup: recordCommand},
+		{name: "replay", args: "in.wtop", nargs: 1, argFiles: true, summary: "browse a recording in the dashboard", setup: replayCommand},
+		{name: "serve", summary: "export metrics to Prometheus, OTLP, InfluxDB or StatsD without the UI", setup: serveCommand},
+		{name: "agent", summary: "serve live snapshots to wtop connect and wtop fleet", setup: agentCommand},
+		{name: "connect", args: "host:port", nargs: 1, summary: "show a remote agent in the dashboard", setup: connectCommand},
+		{name: "fleet", args: "hosts-file", nargs: 1, argFiles: true, summary: "overview of many agents with drill-down", setup: fleetCommand},
+		{name: "web", summary: "serve the dashboard to web browsers", setup: webCommand},
+		{name: "completion", args: "bash|zsh|fish", nargs: 1, argChoices: shellNames, summary: "print a shell completion script", setup: completionCommand},
+		{name: "version", summary: "print the version", setup: versionCommand},
+		{name: "help", args: "[command]", nargs: -1, argChoices: commandNames, summary: "show help for wtop or a command", setup: helpCommand},
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	return names
+}
+
+// flagSet returns the command's flags with its usage text installed.
+func (c *command) flagSet() (*flag.FlagSet, func([]string)) {
+	name := "wtop"
+	if c.name != "" {
+		name += " " + c.name
+	}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	run := c.setup(fs)
+	fs.Usage = func() { c.usage(fs) }
+	return fs, run
+}
+
+func (c *command) run(args []string) {
+	fs, run := c.flagSet()
+	fs.Parse(args)
+	if c.nargs >= 0 && fs.NArg() != c.nargs {
+		fs.Usage()
+		os.Exit(2)
+	}
+	run(fs.Args())
+}
+
+func (c *command) usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	if c == rootCommand {
+		fmt.Fprintf(w, "usage: wtop [flags]\n       wtop <command> [flags] [args]\n\n%s\n\ncommands:\n", c.summary)
+		for _, cmd := range commands {
+			fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintf(w, "\nflags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(w, "\nRun 'wtop help <command>' for the flags of a command.\n")
+		return
+	}
+
+	line := "wtop " + c.name
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		line += " [flags]"
+	}
+	if c.args != "" {
+		line += " " + c.args
+	}
+	fmt.Fprintf(w, "usage: %s\n\n%s.\n", line, strings.ToUpper(c.summary[:1])+c.summary[1:])
+	if hasFlags {
+		fmt.Fprintf(w, "\nflags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+func snapshotCommand(fs *flag.FlagSet) func([]string) {
+	format := fs.String("format", "json", "output format: json")
+	useConfig := configFlags(fs, "")
+	return func([]string) {
+		useConfig()
+		if err := ui.RunOnce(os.Stdout, *format, 0); err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+	}
+}
+
+func batchCommand(fs *flag.FlagSet) func([]string) {
+	format := fs.String("format", "ndjson", "output format: ndjson or csv")
+	useConfig := configFlags(fs, "sampling interval")
+	batchOpts := batchFlags(fs)
+	return func([]string) {
+		cfg := useConfig()
+		opts := batchOpts()
+		opts.Format = *format
+		opts.Interval = cfg.Refresh
+		runBatch(opts)
+	}
+}
+
+func serveCommand(fs *flag.FlagSet) func([]string) {
+	addr := fs.String("prometheus", "", "address to serve Prometheus metrics on, e.g. :9100")
+	useConfig := configFlags(fs, "collection interval")
+	top := fs.Int("top", 0, "export Prometheus gauges for this many top processes")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "OTLP collector endpoint to push metrics to")
+	otlpProtocol := fs.String("otlp-protocol", "http/protobuf", "OTLP protocol: http/protobuf or grpc")
+	var otlpHeaders kvFlag
+	fs.Var(&otlpHeaders, "otlp-header", "extra OTLP request header as key=value (repeatable)")
+	push := pushFlags(fs)
+	flight := flightFlags(fs)
+	historyFile := fs.String("history-file", "", "also record long-range history for the dashboard to this file")
+
+	return func([]string) {
+		cfg := useConfig()
+		opts := ui.ServeOptions{
+			Interval:       cfg.Refresh,
+			PrometheusAddr: *addr,
+			PrometheusTopN: *top,
+		}
+		if *otlpEndpoint != "" {
+			opts.OTLP = &ui.OTLPOptions{Endpoint: *otlpEndpoint, Protocol: *otlpProtocol, Headers: otlpHeaders}
+		}
+		opts.Push = push()
+		opts.Flight = flight()
+		opts.HistoryFile = *historyFile
+		if opts.PrometheusAddr == "" && opts.OTLP == nil && opts.Push == nil && opts.Flight == nil && opts.HistoryFile == "" {
+			opts.PrometheusAddr = ":9100"
+		}
+		if err := ui.Serve(opts); err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+	}
+}
+
+func recordCommand(fs *flag.FlagSet) func([]string) {
+	useConfig := configFlags(fs, "collection interval")
+	duration := fs.Duration("duration", 0, "stop recording after this long (0 = until interrupted)")
+	top := fs.Int("top", 0, "number of processes kept per snapshot (default 256)")
+	return func(args []string) {
+		cfg := useConfig()
+		err := ui.Record(args[0], ui.RecordOptions{Interval: cfg.Refresh, Duration: *duration, TopN: *top})
+		if err != nil {
+			log.Fatalf("wtop: record: %v", err)
+		}
+	}
+}
+
+func replayCommand(fs *flag.FlagSet) func([]string) {
+	useView := viewFlags(fs, false)
+	return func(args []string) {
+		useView()
+		if err := ui.Replay(args[0]); err != nil {
+			log.Fatalf("wtop: replay: %v", err)
+		}
+	}
+}
+
+func agentCommand(fs *flag.FlagSet) func([]string) {
+	listen := fs.String("listen", ":7070", "address to accept wtop connect clients on")
+	token := fs.String("token", os.Getenv("WTOP_TOKEN"), "shared secret clients must present (default $WTOP_TOKEN)")
+	cert := fs.String("tls-cert", "", "TLS certificate file")
+	key := fs.String("tls-key", "", "TLS private key file")
+	useConfig := configFlags(fs, "collection interval")
+	allow := fs.Bool("allow-actions", false, "let clients send SIGTERM/SIGKILL to processes")
+	insecure := fs.Bool("insecure", false, "serve without TLS, sending the token and metrics unencrypted")
+	return func([]string) {
+		cfg := useConfig()
+		err := ui.RunAgent(ui.AgentOptions{
+			Listen:       *listen,
+			Token:        *token,
+			CertFile:     *cert,
+			KeyFile:      *key,
+			Interval:     cfg.Refresh,
+			AllowActions: *allow,
+			Insecure:     *insecure,
+		})
+		if err != nil {
+			log.Fatalf("wtop: agent: %v", err)
+		}
+	}
+}
+
+func connectCommand(fs *flag.FlagSet) func([]string) {
+	token := fs.String("token", os.Getenv("WTOP_TOKEN"), "agent token (default $WTOP_TOKEN)")
+	useTLS := fs.Bool("tls", false, "connect over TLS")
+	ca := fs.String("tls-ca", "", "CA certificate used to verify the agent (implies --tls)")
+	insecure := fs.Bool("tls-insecure", false, "skip agent certificate verification (implies --tls)")
+	useView := viewFlags(fs, false)
+	return func(args []string) {
+		useView()
+		err := ui.Connect(ui.RemoteOptions{
+			Addr:     args[0],
+			Token:    *token,
+			TLS:      *useTLS,
+			CAFile:   *ca,
+			Insecure: *insecure,
+		})
+		if err != nil {
+			log.Fatalf("wtop: connect: %v", err)
+		}
+	}
+}
+
+func fleetCommand(fs *flag.FlagSet) func([]string) {
+	token := fs.String("token", os.Getenv("WTOP_TOKEN"), "agent token shared by every host (default $WTOP_TOKEN)")
+	useTLS := fs.Bool("tls", false, "connect over TLS")
+	ca := fs.String("tls-ca", "", "CA certificate used to verify the agents (implies --tls)")
+	insecure := fs.Bool("tls-insecure", false, "skip agent certificate verification (implies --tls)")
+	useView := viewFlags(fs, false)
+	return func(args []string) {
+		useView()
+		f, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("wtop: fleet: %v", err)
+		}
+		hosts, err := ui.ParseHostList(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("wtop: fleet: %s: %v", args[0], err)
+		}
+
+		err = ui.RunFleet(ui.FleetOptions{
+			Hosts:    hosts,
+			Token:    *token,
+			TLS:      *useTLS,
+			CAFile:   *ca,
+			Insecure: *insecure,
+		})
+		if err != nil {
+			log.Fatalf("wtop: fleet: %v", err)
+		}
+	}
+}
+
+func webCommand(fs *flag.FlagSet) func([]string) {
+	listen := fs.String("listen", ":8080", "address to serve the browser dashboard on")
+	token := fs.String("token", os.Getenv("WTOP_TOKEN"), "token required to view metrics (default $WTOP_TOKEN)")
+	cert := fs.String("tls-cert", "", "serve HTTPS with this certificate")
+	key := fs.String("tls-key", "", "private key for --tls-cert")
+	useConfig := configFlags(fs, "sampling interval")
+	return func([]string) {
+		cfg := useConfig()
+		err := ui.RunWeb(ui.WebOptions{
+			Listen:   *listen,
+			Token:    *token,
+			CertFile: *cert,
+			KeyFile:  *key,
+			Interval: cfg.Refresh,
+		})
+		if err != nil {
+			log.Fatalf("wtop: web: %v", err)
+		}
+	}
+}
+
+func completionCommand(fs *flag.FlagSet) func([]string) {
+	return func(args []string) {
+		if err := writeCompletion(os.Stdout, args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "wtop: %v\n", err)
+			os.Exit(2)
+		}
+	}
+}
+
+func versionCommand(fs *flag.FlagSet) func([]string) {
+	return func([]string) {
+		fmt.Println("wtop", versionString())
+	}
+}
+
+func helpCommand(fs *flag.FlagSet) func([]string) {
+	return func(args []string) {
+		cmd := rootCommand
+		if len(args) > 0 {
+			if cmd = findCommand(args[0]); cmd == nil {
+				fmt.Fprintf(os.Stderr, "wtop: unknown command %q\n", args[0])
+				os.Exit(2)
+			}
+		}
+		cfs, _ := cmd.flagSet()
+		cfs.SetOutput(os.Stdout)
+		cfs.Usage()
+	}
+}
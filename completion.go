@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SwarnenduG07/wtop/ui"
+)
+
+// completionFlag describes one flag for the completion scripts, which are
+// generated from the command table so they never drift from the real flags.
+type completionFlag struct {
+	name    string
+	usage   string
+	value   bool // the flag takes a value
+	file    bool // the value is a path
+	choices []string
+}
+
+// fileFlags take a path as their value.
+var fileFlags = map[string]bool{
+	"config": true, "output": true, "history-file": true, "flight-dir": true,
+	"tls-cert": true, "tls-key": true, "tls-ca": true,
+}
+
+func shellNames() []string { return []string{"bash", "zsh", "fish"} }
+
+func flagChoices(cmd *command, name string) []string {
+	switch name {
+	case "sort":
+		return ui.SortNames()
+	case "theme":
+		return ui.ThemeNames()
+	case "fields":
+		return []string{"system", "processes", "all"}
+	case "otlp-protocol":
+		return []string{"http/protobuf", "grpc"}
+	case "format":
+		switch cmd.name {
+		case "snapshot":
+			return []string{"json"}
+		case "batch":
+			return []string{"ndjson", "csv"}
+		}
+		return []string{"json", "ndjson", "csv"}
+	}
+	return nil
+}
+
+func completionFlags(cmd *command) []completionFlag {
+	fs, _ := cmd.flagSet()
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		_, usage := flag.UnquoteUsage(f)
+		flags = append(flags, completionFlag{
+			name:    f.Name,
+			usage:   usage,
+			value:   !isBool || !b.IsBoolFlag(),
+			file:    fileFlags[f.Name],
+			choices: flagChoices(cmd, f.Name),
+		})
+	})
+	return flags
+}
+
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w)
+	case "zsh":
+		writeZshCompletion(w)
+	case "fish":
+		writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q (want bash, zsh or fish)", shell)
+	}
+	return nil
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `# bash completion for wtop. Load it with: source <(wtop completion bash)
+_wtop() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}" cmd=""
+    if [[ $COMP_CWORD -gt 1 && ${COMP_WORDS[1]} != -* ]]; then
+        cmd="${COMP_WORDS[1]}"
+    fi
+    COMPREPLY=()
+    case "$cmd" in
+`)
+	for _, cmd := range append([]*command{rootCommand}, commands...) {
+		flags := completionFlags(cmd)
+		fmt.Fprintf(w, "    %q)\n", cmd.name)
+
+		var names, files, values []string
+		for _, f := range flags {
+			names = append(names, "--"+f.name)
+			switch {
+			case len(f.choices) > 0:
+				fmt.Fprintf(w, "        if [[ $prev == --%s ]]; then COMPREPLY=($(compgen -W %q -- \"$cur\")); return; fi\n", f.name, strings.Join(f.choices, " "))
+			case f.file:
+				files = append(files, "--"+f.name)
+			case f.value:
+				values = append(values, "--"+f.name)
+			}
+		}
+		if len(files) > 0 {
+			fmt.Fprintf(w, "        case \"$prev\" in %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;; esac\n", strings.Join(files, "|"))
+		}
+		if len(values) > 0 {
+			fmt.Fprintf(w, "        case \"$prev\" in %s) return ;; esac\n", strings.Join(values, "|"))
+		}
+
+		fmt.Fprintf(w, "        if [[ $cur == -* ]]; then\n")
+		fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+		switch {
+		case cmd == rootCommand:
+			fmt.Fprintf(w, "        elif [[ $COMP_CWORD -eq 1 ]]; then\n")
+			fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames(), " "))
+		case cmd.argChoices != nil:
+			fmt.Fprintf(w, "        else\n")
+			fmt.Fprintf(w, "            COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(cmd.argChoices(), " "))
+		case cmd.argFiles:
+			fmt.Fprintf(w, "        else\n")
+			fmt.Fprintf(w, "            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		}
+		fmt.Fprintf(w, "        fi\n        ;;\n")
+	}
+	fmt.Fprintf(w, "    esac\n}\ncomplete -o filenames -F _wtop wtop\n")
+}
+
+// zshQuote escapes text for an _arguments description inside single quotes.
+func zshQuote(s string) string {
+	return strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+func zshSpecs(cmd *command) []string {
+	var specs []string
+	for _, f := range completionFlags(cmd) {
+		spec := "--" + f.name
+		if f.value {
+			spec += "="
+		}
+		spec += "[" + zshQuote(f.usage) + "]"
+		switch {
+		case len(f.choices) > 0:
+			spec += ":" + f.name + ":(" + strings.Join(f.choices, " ") + ")"
+		case f.file:
+			spec += ":file:_files"
+		case f.value:
+			spec += ":" + f.name + ": "
+		}
+		specs = append(specs, "'"+spec+"'")
+	}
+	switch {
+	case cmd.argChoices != nil:
+		specs = append(specs, "'*:"+cmd.name+":("+strings.Join(cmd.argChoices(), " ")+")'")
+	case cmd.argFiles:
+		specs = append(specs, "'*:file:_files'")
+	}
+	return specs
+}
+
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprintf(w, "#compdef wtop\n# zsh completion for wtop. Load it with: source <(wtop completion zsh)\n\n_wtop() {\n")
+	fmt.Fprintf(w, "  if (( CURRENT > 2 )) && [[ $words[2] != -* ]]; then\n")
+	fmt.Fprintf(w, "    local cmd=$words[2]\n    shift words\n    (( CURRENT-- ))\n    case $cmd in\n")
+	for _, cmd := range commands {
+		specs := zshSpecs(cmd)
+		if len(specs) == 0 {
+			fmt.Fprintf(w, "      %s)\n        _message 'no arguments'\n        ;;\n", cmd.name)
+			continue
+		}
+		fmt.Fprintf(w, "      %s)\n        _arguments -s \\\n          %s\n        ;;\n", cmd.name, strings.Join(specs, " \\\n          "))
+	}
+	fmt.Fprintf(w, "    esac\n    return\n  fi\n\n")
+
+	fmt.Fprintf(w, "  local -a commands\n  commands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "    '%s:%s'\n", cmd.name, zshQuote(cmd.summary))
+	}
+	fmt.Fprintf(w, "  )\n  if (( CURRENT == 2 )) && [[ $PREFIX != -* ]]; then\n")
+	fmt.Fprintf(w, "    _describe -t commands 'wtop command' commands\n    return\n  fi\n")
+	fmt.Fprintf(w, "  _arguments -s \\\n    %s\n}\n\n", strings.Join(zshSpecs(rootCommand), " \\\n    "))
+	fmt.Fprintf(w, "if [[ $zsh_eval_context[-1] == loadautofunc ]]; then\n  _wtop \"$@\"\nelse\n  compdef _wtop wtop\nfi\n")
+}
+
+// fishQuote quotes s as a single-quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintf(w, "# fish completion for wtop. Load it with: wtop completion fish | source\n")
+	fmt.Fprintf(w, "complete -c wtop -f\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c wtop -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+	}
+	for _, cmd := range append([]*command{rootCommand}, commands...) {
+		cond := "__fish_use_subcommand"
+		if cmd != rootCommand {
+			cond = fishQuote("__fish_seen_subcommand_from " + cmd.name)
+		}
+		for _, f := range completionFlags(cmd) {
+			line := fmt.Sprintf("complete -c wtop -n %s -l %s", cond, f.name)
+			switch {
+			case len(f.choices) > 0:
+				line += " -x -a " + fishQuote(strings.Join(f.choices, " "))
+			case f.file:
+				line += " -r -F"
+			case f.value:
+				line += " -x"
+			}
+			fmt.Fprintf(w, "%s -d %s\n", line, fishQuote(f.usage))
+		}
+		switch {
+		case cmd.argChoices != nil:
+			fmt.Fprintf(w, "complete -c wtop -n %s -a %s\n", cond, fishQuote(strings.Join(cmd.argChoices(), " ")))
+		case cmd.argFiles:
+			fmt.Fprintf(w, "complete -c wtop -n %s -F\n", cond)
+		}
+	}
+}
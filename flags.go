@@ -0,0 +1,269 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SwarnenduG07/wtop/ui"
+)
+
+// viewFlags registers --config and the flags that override it on fs, and
+// returns a function that loads the file after parsing, applies the
+// overrides and makes the result the dashboard's configuration. local adds
+// the flags that only make sense when collecting on this machine. A missing
+// default config file is fine; a missing --config file or any invalid
+// setting is fatal.
+func viewFlags(fs *flag.FlagSet, local bool) func() {
+	path := fs.String("config", ui.DefaultConfigPath(), "configuration file")
+	var interval *time.Duration
+	var noGPU *bool
+	if local {
+		interval = fs.Duration("interval", 0, "refresh interval (default from the config file, 2s)")
+		noGPU = fs.Bool("no-gpu", false, "do not collect GPU metrics and hide the GPU panel")
+	}
+	var pids pidFlag
+	fs.Var(&pids, "pid", "only show the processes with these `PIDs` (comma-separated or repeatable)")
+	var users csvFlag
+	fs.Var(&users, "user", "only show processes owned by these `users` (comma-separated or repeatable)")
+	filter := fs.String("filter", "", "initial process filter")
+	sortBy := fs.String("sort", "", "process sort order: "+strings.Join(ui.SortNames(), ", "))
+	layout := fs.String("layout", "", "start with this layout from the config file")
+	theme := fs.String("theme", "", "color theme: "+strings.Join(ui.ThemeNames(), ", "))
+	noMouse := fs.Bool("no-mouse", false, "disable mouse support")
+	ascii := fs.Bool("ascii", false, "accessible ASCII-only rendering with usage levels marked in text")
+
+	return func() {
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+		})
+		cfg, err := ui.LoadConfig(*path, set["config"])
+		if err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+		if set["interval"] {
+			cfg.Refresh = *interval
+		}
+		if set["no-gpu"] {
+			cfg.GPU = !*noGPU
+		}
+		if len(pids) > 0 {
+			cfg.PIDs = pids
+		}
+		if len(users) > 0 {
+			cfg.Users = users
+		}
+		if set["filter"] {
+			cfg.Filter = *filter
+		}
+		if set["sort"] {
+			cfg.Sort = *sortBy
+		}
+		if set["layout"] {
+			cfg.Layout = *layout
+		}
+		if set["theme"] {
+			cfg.Theme = *theme
+		}
+		if *noMouse {
+			cfg.Mouse = false
+		}
+		if *ascii {
+			cfg.ASCII = true
+		}
+		if err := cfg.Validate("command line"); err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+		ui.UseConfig(cfg)
+	}
+}
+
+// configFlags registers --config on fs for the commands without a view, and
+// --interval unless intervalUsage is empty. The returned function loads the
+// file after parsing and makes it the active configuration, so the refresh
+// interval and gpu setting apply to headless collection as well.
+func configFlags(fs *flag.FlagSet, intervalUsage string) func() *ui.Config {
+	path := fs.String("config", ui.DefaultConfigPath(), "configuration file")
+	var interval *time.Duration
+	if intervalUsage != "" {
+		interval = fs.Duration("interval", 0, intervalUsage+" (default from the config file, 2s)")
+	}
+	return func() *ui.Config {
+		set := map[string]bool{}
+		fs.Visit(func(f *flag.Flag) {
+			set[f.Name] = true
+		})
+		cfg, err := ui.LoadConfig(*path, set["config"])
+		if err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+		if set["interval"] {
+			cfg.Refresh = *interval
+		}
+		if err := cfg.Validate("command line"); err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+		ui.UseConfig(cfg)
+		return cfg
+	}
+}
+
+// pushFlags registers the InfluxDB and StatsD flags on fs and returns a
+// function that builds the options after parsing, or nil if neither is set.
+func pushFlags(fs *flag.FlagSet) func() *ui.PushOptions {
+	influx := fs.String("influx-url", "", "push InfluxDB line protocol to this http(s):// write URL or udp://host:port")
+	influxToken := fs.String("influx-token", "", "InfluxDB API token for HTTP writes")
+	statsd := fs.String("statsd", "", "push StatsD gauges to this host:port")
+	prefix := fs.String("push-prefix", "wtop.", "prefix for InfluxDB measurements and StatsD metric names")
+	flush := fs.Duration("push-flush", 0, "batch InfluxDB and StatsD pushes to this interval (0 = every snapshot)")
+	var tags kvFlag
+	fs.Var(&tags, "push-tag", "tag added to every InfluxDB point and StatsD gauge as key=value (repeatable)")
+	return func() *ui.PushOptions {
+		if *influx == "" && *statsd == "" {
+			return nil
+		}
+		return &ui.PushOptions{
+			InfluxURL:     *influx,
+			InfluxToken:   *influxToken,
+			StatsDAddr:    *statsd,
+			Prefix:        *prefix,
+			Tags:          tags,
+			FlushInterval: *flush,
+		}
+	}
+}
+
+// flightFlags registers the flight recorder flags on fs. The recorder is on
+// when --flight or any of its triggers is given.
+func flightFlags(fs *flag.FlagSet) func() *ui.FlightOptions {
+	enabled := fs.Bool("flight", false, "keep recent snapshots in memory and dump them on a trigger or the d key")
+	window := fs.Duration("flight-window", 5*time.Minute, "how much history the flight recorder keeps")
+	dir := fs.String("flight-dir", ".", "directory flight recorder dumps are written to")
+	cooldown := fs.Duration("flight-cooldown", 0, "minimum time between automatic dumps (default the window)")
+	cpuAbove := fs.Float64("flight-cpu", 0, "dump when total CPU stays at or above this percent")
+	cpuFor := fs.Duration("flight-cpu-for", 10*time.Second, "how long CPU must stay above --flight-cpu")
+	var memBelow sizeFlag
+	fs.Var(&memBelow, "flight-mem-below", "dump when available memory drops below this size, e.g. 512M")
+	var watch listFlag
+	fs.Var(&watch, "flight-watch", "dump when this process (name or PID) exits (repeatable)")
+	return func() *ui.FlightOptions {
+		if !*enabled && *cpuAbove <= 0 && memBelow == 0 && len(watch) == 0 {
+			return nil
+		}
+		return &ui.FlightOptions{
+			Window:   *window,
+			Dir:      *dir,
+			Cooldown: *cooldown,
+			CPUAbove: *cpuAbove,
+			CPUFor:   *cpuFor,
+			MemBelow: uint64(memBelow),
+			Watch:    watch,
+		}
+	}
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// pidFlag collects PIDs given as repeated flags or comma-separated lists.
+type pidFlag []int32
+
+func (p *pidFlag) String() string {
+	parts := make([]string, len(*p))
+	for i, pid := range *p {
+		parts[i] = strconv.Itoa(int(pid))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (p *pidFlag) Set(value string) error {
+	for _, field := range strings.Split(value, ",") {
+		pid, err := strconv.ParseInt(strings.TrimSpace(field), 10, 32)
+		if err != nil || pid <= 0 {
+			return fmt.Errorf("invalid PID %q", field)
+		}
+		*p = append(*p, int32(pid))
+	}
+	return nil
+}
+
+// csvFlag collects values given as repeated flags or comma-separated lists.
+type csvFlag []string
+
+func (c *csvFlag) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *csvFlag) Set(value string) error {
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			*c = append(*c, field)
+		}
+	}
+	return nil
+}
+
+// sizeFlag parses byte sizes with an optional K, M, G or T suffix (powers of
+// 1024), matching how sizes are shown in the dashboard.
+type sizeFlag uint64
+
+func (s *sizeFlag) String() string {
+	return strconv.FormatUint(uint64(*s), 10)
+}
+
+func (s *sizeFlag) Set(value string) error {
+	v := strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(value), "B"))
+	v = strings.TrimSuffix(v, "I")
+	mult := uint64(1)
+	if n := len(v); n > 0 {
+		switch v[n-1] {
+		case 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		case 'T':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			v = v[:n-1]
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q", value)
+	}
+	*s = sizeFlag(n * float64(mult))
+	return nil
+}
+
+type kvFlag map[string]string
+
+func (h *kvFlag) String() string {
+	return fmt.Sprint(map[string]string(*h))
+}
+
+func (h *kvFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("%q is not key=value", value)
+	}
+	if *h == nil {
+		*h = make(kvFlag)
+	}
+	(*h)[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	return nil
+}
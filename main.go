@@ -1,14 +1,171 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/SwarnenduG07/wtop/ui"
 )
 
+// version is set at build time with -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
 func main() {
-	dashboard := ui.NewDashboard()
-	if err := dashboard.Run(); err != nil {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		cmd := findCommand(os.Args[1])
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "wtop: unknown command %q\nRun 'wtop help' for usage.\n", os.Args[1])
+			os.Exit(2)
+		}
+		cmd.run(os.Args[2:])
+		return
+	}
+	rootCommand.run(os.Args[1:])
+}
+
+// dashboard is the interactive UI started by plain `wtop`. --once and
+// --batch predate the snapshot and batch commands and are kept for existing
+// scripts.
+func dashboard(fs *flag.FlagSet) func([]string) {
+	showVersion := fs.Bool("version", false, "print the version and exit")
+	useView := viewFlags(fs, true)
+	once := fs.Bool("once", false, "print a single snapshot and exit (same as wtop snapshot)")
+	batch := fs.Bool("batch", false, "write one record per interval instead of starting the UI (same as wtop batch)")
+	format := fs.String("format", "", "output format: json for --once, ndjson or csv for --batch")
+	batchOpts := batchFlags(fs)
+	prometheus := fs.String("prometheus", "", "also serve Prometheus metrics on this address, e.g. :9100")
+	promTop := fs.Int("prometheus-top", 0, "export gauges for this many top processes")
+	otlpEndpoint := fs.String("otlp-endpoint", "", "also push metrics to this OTLP collector endpoint")
+	otlpProtocol := fs.String("otlp-protocol", "http/protobuf", "OTLP protocol: http/protobuf or grpc")
+	push := pushFlags(fs)
+	flight := flightFlags(fs)
+	historyFile := fs.String("history-file", ui.DefaultHistoryPath(), "long-range history kept between runs (empty disables)")
+
+	return func([]string) {
+		if *showVersion {
+			fmt.Println("wtop", versionString())
+			return
+		}
+		useView()
+
+		if *once {
+			if *format == "" {
+				*format = "json"
+			}
+			if err := ui.RunOnce(os.Stdout, *format, 0); err != nil {
+				log.Fatalf("wtop: %v", err)
+			}
+			return
+		}
+		if *batch {
+			opts := batchOpts()
+			opts.Format = *format
+			runBatch(opts)
+			return
+		}
+
+		dashboard := ui.NewDashboard()
+		if *prometheus != "" {
+			if err := dashboard.EnablePrometheus(*prometheus, *promTop); err != nil {
+				log.Fatalf("wtop: prometheus: %v", err)
+			}
+		}
+		if *otlpEndpoint != "" {
+			if err := dashboard.EnableOTLP(ui.OTLPOptions{Endpoint: *otlpEndpoint, Protocol: *otlpProtocol}); err != nil {
+				log.Fatalf("wtop: otlp: %v", err)
+			}
+		}
+		if opts := push(); opts != nil {
+			if err := dashboard.EnablePush(*opts); err != nil {
+				log.Fatalf("wtop: push: %v", err)
+			}
+		}
+		if opts := flight(); opts != nil {
+			dashboard.EnableFlightRecorder(*opts)
+		}
+		if *historyFile != "" {
+			if err := dashboard.EnableHistory(*historyFile); err != nil {
+				log.Printf("wtop: %v", err)
+			}
+		}
+		if err := dashboard.Run(); err != nil {
+			log.Fatalf("wtop: %v", err)
+		}
+	}
+}
+
+// batchFlags registers the record selection flags shared by wtop batch and
+// the legacy --batch mode. The interval comes from the configuration, which
+// --interval overrides.
+func batchFlags(fs *flag.FlagSet) func() ui.BatchOptions {
+	samples := fs.Int("samples", 0, "stop after this many records (0 = unlimited)")
+	fields := fs.String("fields", "system", "record contents: system, processes or all")
+	top := fs.Int("top", 10, "number of processes per record")
+	output := fs.String("output", "", "append records to this file instead of writing to stdout")
+	return func() ui.BatchOptions {
+		opts := ui.BatchOptions{Fields: *fields, Samples: *samples, TopN: *top, Output: os.Stdout}
+		if *output != "" {
+			f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				log.Fatalf("wtop: %v", err)
+			}
+			opts.Output = f
+		}
+		return opts
+	}
+}
+
+func runBatch(opts ui.BatchOptions) {
+	err := ui.RunBatch(opts)
+	if c, ok := opts.Output.(io.Closer); ok && opts.Output != os.Stdout {
+		c.Close()
+	}
+	if err != nil {
 		log.Fatalf("wtop: %v", err)
 	}
 }
+
+// versionString reports the version stamped in by the build, falling back
+// to the module version and VCS revision recorded by go build.
+func versionString() string {
+	if version != "dev" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var revision, modified string
+	var built time.Time
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = "-dirty"
+			}
+		case "vcs.time":
+			built, _ = time.Parse(time.RFC3339, s.Value)
+		}
+	}
+	if revision == "" {
+		return version
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if built.IsZero() {
+		return fmt.Sprintf("%s (%s%s)", version, revision, modified)
+	}
+	return fmt.Sprintf("%s (%s%s, %s)", version, revision, modified, built.Format("2006-01-02"))
+}
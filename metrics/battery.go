@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type BatteryInfo struct {
+	Name              string  `json:"name"`
+	Status            string  `json:"status"`
+	Capacity          float64 `json:"capacity"`
+	EnergyNow         float64 `json:"energyNow"`
+	EnergyFull        float64 `json:"energyFull"`
+	EnergyFullDesign  float64 `json:"energyFullDesign"`
+	PowerNow          float64 `json:"powerNow"`
+	TimeToEmptyReport float64 `json:"timeToEmptyReport"`
+	TimeToFullReport  float64 `json:"timeToFullReport"`
+}
+
+type PowerSupplyInfo struct {
+	Batteries []*BatteryInfo `json:"batteries"`
+	ACPresent bool           `json:"acPresent"`
+	ACOnline  bool           `json:"acOnline"`
+}
+
+func (b *BatteryInfo) Charging() bool {
+	return strings.EqualFold(b.Status, "Charging")
+}
+
+func (b *BatteryInfo) Discharging() bool {
+	return strings.EqualFold(b.Status, "Discharging")
+}
+
+// Health is the remaining full-charge capacity as a percentage of the
+// design capacity, or 0 when the driver does not report both.
+func (b *BatteryInfo) Health() float64 {
+	if b.EnergyFullDesign <= 0 || b.EnergyFull <= 0 {
+		return 0
+	}
+	return b.EnergyFull / b.EnergyFullDesign * 100
+}
+
+// Battery folds every battery into one so that dual-battery laptops show a
+// single charge level and power draw.
+func (p *PowerSupplyInfo) Battery() *BatteryInfo {
+	if p == nil || len(p.Batteries) == 0 {
+		return nil
+	}
+	if len(p.Batteries) == 1 {
+		return p.Batteries[0]
+	}
+
+	total := &BatteryInfo{Name: "total", Status: "Unknown"}
+	var capacitySum float64
+	for _, b := range p.Batteries {
+		total.EnergyNow += b.EnergyNow
+		total.EnergyFull += b.EnergyFull
+		total.EnergyFullDesign += b.EnergyFullDesign
+		total.PowerNow += b.PowerNow
+		capacitySum += b.Capacity
+		switch {
+		case b.Discharging():
+			total.Status = b.Status
+		case b.Charging() && !total.Discharging():
+			total.Status = b.Status
+		case total.Status == "Unknown":
+			total.Status = b.Status
+		}
+	}
+	if total.EnergyFull > 0 {
+		total.Capacity = total.EnergyNow / total.EnergyFull * 100
+	} else {
+		total.Capacity = capacitySum / float64(len(p.Batteries))
+	}
+	return total
+}
+
+func GetPowerSupply() (*PowerSupplyInfo, error) {
+	return ReadPowerSupply(filepath.Join(SysfsRoot, "class", "power_supply"))
+}
+
+func ReadPowerSupply(root string) (*PowerSupplyInfo, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &PowerSupplyInfo{}
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		switch readSysfsString(filepath.Join(dir, "type")) {
+		case "Battery":
+			// Peripheral batteries (mice, headsets) report scope=Device.
+			if readSysfsString(filepath.Join(dir, "scope")) == "Device" {
+				continue
+			}
+			if present, ok := readSysfsFloat(filepath.Join(dir, "present")); ok && present == 0 {
+				continue
+			}
+			info.Batteries = append(info.Batteries, readBattery(entry.Name(), dir))
+		case "Mains", "USB":
+			online, ok := readSysfsFloat(filepath.Join(dir, "online"))
+			if !ok {
+				continue
+			}
+			info.ACPresent = true
+			if online > 0 {
+				info.ACOnline = true
+			}
+		}
+	}
+
+	sort.Slice(info.Batteries, func(i, j int) bool {
+		return naturalLess(info.Batteries[i].Name, info.Batteries[j].Name)
+	})
+	return info, nil
+}
+
+func readBattery(name, dir string) *BatteryInfo {
+	read := func(attr string) float64 {
+		v, _ := readSysfsFloat(filepath.Join(dir, attr))
+		return v
+	}
+
+	b := &BatteryInfo{
+		Name:     name,
+		Status:   readSysfsString(filepath.Join(dir, "status")),
+		Capacity: read("capacity"),
+	}
+	if b.Status == "" {
+		b.Status = "Unknown"
+	}
+
+	voltage := read("voltage_now") / 1e6
+	designVoltage := read("voltage_min_design") / 1e6
+	if designVoltage <= 0 {
+		designVoltage = voltage
+	}
+
+	// Drivers report either energy (µWh) or charge (µAh); charge is
+	// converted to energy with the design voltage.
+	if fileExists(filepath.Join(dir, "energy_now")) {
+		b.EnergyNow = read("energy_now") / 1e6
+		b.EnergyFull = read("energy_full") / 1e6
+		b.EnergyFullDesign = read("energy_full_design") / 1e6
+	} else {
+		b.EnergyNow = read("charge_now") / 1e6 * designVoltage
+		b.EnergyFull = read("charge_full") / 1e6 * designVoltage
+		b.EnergyFullDesign = read("charge_full_design") / 1e6 * designVoltage
+	}
+
+	if power := read("power_now"); power != 0 {
+		b.PowerNow = absFloat(power) / 1e6
+	} else if current := read("current_now"); current != 0 && voltage > 0 {
+		b.PowerNow = absFloat(current) / 1e6 * voltage
+	}
+
+	if b.Capacity == 0 && b.EnergyFull > 0 {
+		b.Capacity = b.EnergyNow / b.EnergyFull * 100
+	}
+	b.TimeToEmptyReport = read("time_to_empty_now")
+	b.TimeToFullReport = read("time_to_full_now")
+	return b
+}
+
+func absFloat(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -8,36 +8,36 @@ import (
 )
 
 type GPUInfo struct {
-	Index              int
-	Name               string
-	Driver             string
-	Utilization        float64
-	MemoryUsed         float64
-	MemoryTotal        float64
-	Temperature        float64
-	PowerUsage         float64
-	PowerLimit         float64
-	FanSpeed           float64
-	FanRPM             int
-	ClockCore          int
-	ClockMemory        int
-	ClockSM            int
-	PerformanceState   string
-	ThrottleReasons    []string
-	MemoryUtilization  float64
-	PCIeGen            int
-	PCIeWidth          int
-	ComputeMode        string
-	MemoryBusWidth     int
-	PowerState         string
-	TempSlowdown       float64
+	Index             int      `json:"index"`
+	Name              string   `json:"name"`
+	Driver            string   `json:"driver"`
+	Utilization       float64  `json:"utilization"`
+	MemoryUsed        float64  `json:"memoryUsed"`
+	MemoryTotal       float64  `json:"memoryTotal"`
+	Temperature       float64  `json:"temperature"`
+	PowerUsage        float64  `json:"powerUsage"`
+	PowerLimit        float64  `json:"powerLimit"`
+	FanSpeed          float64  `json:"fanSpeed"`
+	FanRPM            int      `json:"fanRpm"`
+	ClockCore         int      `json:"clockCore"`
+	ClockMemory       int      `json:"clockMemory"`
+	ClockSM           int      `json:"clockSm"`
+	PerformanceState  string   `json:"performanceState"`
+	ThrottleReasons   []string `json:"throttleReasons"`
+	MemoryUtilization float64  `json:"memoryUtilization"`
+	PCIeGen           int      `json:"pcieGen"`
+	PCIeWidth         int      `json:"pcieWidth"`
+	ComputeMode       string   `json:"computeMode"`
+	MemoryBusWidth    int      `json:"memoryBusWidth"`
+	PowerState        string   `json:"powerState"`
+	TempSlowdown      float64  `json:"tempSlowdown"`
 }
 
 type GPUProcess struct {
-	PID         int
-	ProcessName string
-	MemoryUsed  float64
-	Type        string
+	PID         int     `json:"pid"`
+	ProcessName string  `json:"processName"`
+	MemoryUsed  float64 `json:"memoryUsed"`
+	Type        string  `json:"type"`
 }
 
 func getNvidiaSmiCmd() string {
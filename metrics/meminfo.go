@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KernelMemory carries the /proc/meminfo fields that gopsutil's
+// VirtualMemoryStat does not expose, plus zram device statistics.
+type KernelMemory struct {
+	KernelStack    uint64        `json:"kernelStack"`
+	ShmemHugePages uint64        `json:"shmemHugePages"`
+	FileHugePages  uint64        `json:"fileHugePages"`
+	Zswap          uint64        `json:"zswap"`
+	Zswapped       uint64        `json:"zswapped"`
+	Zram           []*ZramDevice `json:"zram"`
+}
+
+type ZramDevice struct {
+	Name      string `json:"name"`
+	OrigData  uint64 `json:"origData"`
+	ComprData uint64 `json:"comprData"`
+	MemUsed   uint64 `json:"memUsed"`
+}
+
+func (z *ZramDevice) Ratio() float64 {
+	if z.MemUsed == 0 {
+		return 0
+	}
+	return float64(z.OrigData) / float64(z.MemUsed)
+}
+
+func GetKernelMemory() (*KernelMemory, error) {
+	data, err := os.ReadFile(filepath.Join(ProcRoot, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	fields := ParseMeminfo(string(data))
+	km := &KernelMemory{
+		KernelStack:    fields["KernelStack"],
+		ShmemHugePages: fields["ShmemHugePages"],
+		FileHugePages:  fields["FileHugePages"],
+		Zswap:          fields["Zswap"],
+		Zswapped:       fields["Zswapped"],
+		Zram:           readZramDevices(filepath.Join(SysfsRoot, "block")),
+	}
+	return km, nil
+}
+
+// ParseMeminfo returns every /proc/meminfo field in bytes. Fields without a
+// unit (the HugePages_* counts) are returned as-is.
+func ParseMeminfo(data string) map[string]uint64 {
+	fields := make(map[string]uint64)
+	for _, line := range strings.Split(data, "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Fields(rest)
+		if len(parts) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(parts) > 1 && parts[1] == "kB" {
+			value *= 1024
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+func readZramDevices(root string) []*ZramDevice {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	var devices []*ZramDevice
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "zram") {
+			continue
+		}
+		// mm_stat: orig_data_size compr_data_size mem_used_total ...
+		stat := strings.Fields(readSysfsString(filepath.Join(root, entry.Name(), "mm_stat")))
+		if len(stat) < 3 {
+			continue
+		}
+		orig, _ := strconv.ParseUint(stat[0], 10, 64)
+		compr, _ := strconv.ParseUint(stat[1], 10, 64)
+		used, _ := strconv.ParseUint(stat[2], 10, 64)
+		if orig == 0 {
+			continue
+		}
+		devices = append(devices, &ZramDevice{
+			Name:      entry.Name(),
+			OrigData:  orig,
+			ComprData: compr,
+			MemUsed:   used,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return naturalLess(devices[i].Name, devices[j].Name)
+	})
+	return devices
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ProcRoot is where procfs is mounted, overridable like SysfsRoot.
+var ProcRoot = "/proc"
+
+type PressureAvg struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
+type Pressure struct {
+	Some    PressureAvg `json:"some"`
+	Full    PressureAvg `json:"full"`
+	HasFull bool        `json:"hasFull"`
+}
+
+type PressureStats struct {
+	CPU    *Pressure `json:"cpu"`
+	Memory *Pressure `json:"memory"`
+	IO     *Pressure `json:"io"`
+}
+
+type CgroupPressure struct {
+	Path string
+	PressureStats
+}
+
+func GetPressure() (*PressureStats, error) {
+	return readPressureDir(filepath.Join(ProcRoot, "pressure"), "")
+}
+
+// GetCgroupPressure reads the PSI files of the cgroup v2 group that pid
+// belongs to.
+func GetCgroupPressure(pid int32) (*CgroupPressure, error) {
+	data, err := os.ReadFile(filepath.Join(ProcRoot, strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	path := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			path = strings.TrimPrefix(line, "0::")
+			break
+		}
+	}
+	if path == "" {
+		return nil, errors.New("process is not in a cgroup v2 hierarchy")
+	}
+
+	stats, err := readPressureDir(filepath.Join(SysfsRoot, "fs", "cgroup", path), ".pressure")
+	if err != nil {
+		return nil, err
+	}
+	return &CgroupPressure{Path: path, PressureStats: *stats}, nil
+}
+
+func readPressureDir(dir, suffix string) (*PressureStats, error) {
+	stats := &PressureStats{}
+	var firstErr error
+	for _, res := range []struct {
+		name string
+		dst  **Pressure
+	}{
+		{"cpu", &stats.CPU},
+		{"memory", &stats.Memory},
+		{"io", &stats.IO},
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, res.name+suffix))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		p, err := ParsePressure(string(data))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		*res.dst = p
+	}
+	if stats.CPU == nil && stats.Memory == nil && stats.IO == nil {
+		return nil, firstErr
+	}
+	return stats, nil
+}
+
+// ParsePressure parses the contents of a PSI file:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func ParsePressure(data string) (*Pressure, error) {
+	p := &Pressure{}
+	seen := false
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var avg *PressureAvg
+		switch fields[0] {
+		case "some":
+			avg = &p.Some
+		case "full":
+			avg = &p.Full
+			p.HasFull = true
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "avg10":
+				avg.Avg10, _ = strconv.ParseFloat(value, 64)
+			case "avg60":
+				avg.Avg60, _ = strconv.ParseFloat(value, 64)
+			case "avg300":
+				avg.Avg300, _ = strconv.ParseFloat(value, 64)
+			case "total":
+				avg.Total, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+		seen = true
+	}
+	if !seen {
+		return nil, errors.New("no pressure lines found")
+	}
+	return p, nil
+}
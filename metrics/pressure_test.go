@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePressure(t *testing.T) {
+	p, err := ParsePressure("some avg10=1.50 avg60=0.75 avg300=0.25 total=123456\n" +
+		"full avg10=3.25 avg60=2.00 avg300=1.00 total=99\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Pressure{
+		Some:    PressureAvg{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25, Total: 123456},
+		Full:    PressureAvg{Avg10: 3.25, Avg60: 2, Avg300: 1, Total: 99},
+		HasFull: true,
+	}
+	if *p != want {
+		t.Errorf("got %+v, want %+v", *p, want)
+	}
+
+	// Kernels before 5.13 have no full line for CPU.
+	p, err = ParsePressure("some avg10=0.10 avg60=0.00 avg300=0.00 total=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.HasFull || p.Some.Avg10 != 0.1 {
+		t.Errorf("some only: got %+v", *p)
+	}
+
+	for _, bad := range []string{"", "garbage", "other avg10=1"} {
+		if _, err := ParsePressure(bad); err == nil {
+			t.Errorf("ParsePressure(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestGetPressure(t *testing.T) {
+	defer setRoots(filepath.Join("testdata", "proc"), filepath.Join("testdata", "sys"))()
+
+	stats, err := GetPressure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.CPU == nil || stats.CPU.Some.Avg10 != 1.5 || stats.CPU.HasFull {
+		t.Errorf("cpu = %+v", stats.CPU)
+	}
+	if stats.Memory == nil || !stats.Memory.HasFull || stats.Memory.Full.Avg10 != 3.25 {
+		t.Errorf("memory = %+v", stats.Memory)
+	}
+	// An unreadable file leaves its resource out without failing the rest.
+	if stats.IO != nil {
+		t.Errorf("io = %+v, want nil", stats.IO)
+	}
+}
+
+func TestGetCgroupPressure(t *testing.T) {
+	defer setRoots(filepath.Join("testdata", "proc"), filepath.Join("testdata", "sys"))()
+
+	cg, err := GetCgroupPressure(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cg.Path != "/user.slice/app.scope" {
+		t.Errorf("path = %q", cg.Path)
+	}
+	if cg.CPU == nil || cg.CPU.Some.Avg10 != 12 || cg.CPU.Full.Total != 555 {
+		t.Errorf("cpu = %+v", cg.CPU)
+	}
+	if _, err := GetCgroupPressure(7); err == nil {
+		t.Error("GetCgroupPressure of a missing process succeeded")
+	}
+}
+
+// setRoots points ProcRoot and SysfsRoot at fixtures and returns a function
+// restoring them.
+func setRoots(proc, sys string) func() {
+	oldProc, oldSys := ProcRoot, SysfsRoot
+	ProcRoot, SysfsRoot = proc, sys
+	return func() { ProcRoot, SysfsRoot = oldProc, oldSys }
+}
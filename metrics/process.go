@@ -1,7 +1,10 @@
 package metrics
 
 import (
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/SwarnenduG07/wtop/types"
@@ -144,3 +147,62 @@ func GetTopProcesses(limit int) []*types.ProcessInfo {
 	}
 	return processInfos
 }
+
+// FillMemoryDetail adds proportional (PSS) and unique (USS) set sizes from
+// smaps_rollup and the swapped-out size from status. The kernel walks the
+// whole address space to produce smaps_rollup, so callers only do this for
+// the rows they actually show.
+func FillMemoryDetail(info *types.ProcessInfo) error {
+	if info.MemDetail {
+		return nil
+	}
+	dir := filepath.Join(ProcRoot, strconv.Itoa(int(info.PID)))
+
+	data, err := os.ReadFile(filepath.Join(dir, "smaps_rollup"))
+	if err != nil {
+		return err
+	}
+	rollup := ParseMeminfo(string(data))
+	info.PSS = rollup["Pss"]
+	info.USS = rollup["Private_Clean"] + rollup["Private_Dirty"]
+
+	if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		info.Swap = ParseMeminfo(string(status))["VmSwap"]
+	} else {
+		info.Swap = rollup["Swap"]
+	}
+	info.MemDetail = true
+	return nil
+}
+
+// FindProcesses returns the PIDs whose command name is name. The kernel
+// truncates comm to 15 bytes, so longer names are compared on that prefix.
+func FindProcesses(name string) []int32 {
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	entries, err := os.ReadDir(ProcRoot)
+	if err != nil {
+		return nil
+	}
+	var pids []int32
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(ProcRoot, entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(comm)) == name {
+			pids = append(pids, int32(pid))
+		}
+	}
+	return pids
+}
+
+func ProcessExists(pid int32) bool {
+	_, err := os.Stat(filepath.Join(ProcRoot, strconv.Itoa(int(pid))))
+	return err == nil
+}
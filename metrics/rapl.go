@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type RAPLZone struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	EnergyUJ    uint64 `json:"energyUj"`
+	MaxEnergyUJ uint64 `json:"maxEnergyUj"`
+}
+
+func (z *RAPLZone) IsPackage() bool {
+	return strings.HasPrefix(z.Name, "package")
+}
+
+func (z *RAPLZone) IsDRAM() bool {
+	return z.Name == "dram"
+}
+
+func GetRAPLZones() ([]*RAPLZone, error) {
+	return ReadRAPLZones(filepath.Join(SysfsRoot, "class", "powercap"))
+}
+
+// ReadRAPLZones lists the RAPL energy counters under a powercap directory.
+// AMD processors are exposed through the same intel-rapl driver; the MMIO
+// interface mirrors the package zones and is skipped to avoid double counting.
+func ReadRAPLZones(root string) ([]*RAPLZone, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []*RAPLZone
+	for _, entry := range entries {
+		id := entry.Name()
+		if !strings.Contains(id, "rapl") || strings.Contains(id, "mmio") {
+			continue
+		}
+		// The top-level control types (intel-rapl, amd-rapl) have no counters.
+		if !strings.Contains(id, ":") {
+			continue
+		}
+		dir := filepath.Join(root, id)
+		energy, ok := readSysfsFloat(filepath.Join(dir, "energy_uj"))
+		if !ok {
+			continue
+		}
+		maxEnergy, _ := readSysfsFloat(filepath.Join(dir, "max_energy_range_uj"))
+		zones = append(zones, &RAPLZone{
+			ID:          id,
+			Name:        readSysfsString(filepath.Join(dir, "name")),
+			EnergyUJ:    uint64(energy),
+			MaxEnergyUJ: uint64(maxEnergy),
+		})
+	}
+
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].ID < zones[j].ID
+	})
+	return zones, nil
+}
+
+// RAPLWatts converts the energy consumed between two readings of the same
+// zone into average watts. Counters wrap at MaxEnergyUJ; a counter that went
+// backwards without a known range is treated as a reset and yields false.
+func RAPLWatts(prev, cur *RAPLZone, elapsed time.Duration) (float64, bool) {
+	if prev == nil || cur == nil || elapsed <= 0 {
+		return 0, false
+	}
+	var delta uint64
+	switch {
+	case cur.EnergyUJ >= prev.EnergyUJ:
+		delta = cur.EnergyUJ - prev.EnergyUJ
+	case cur.MaxEnergyUJ > prev.EnergyUJ:
+		delta = cur.MaxEnergyUJ - prev.EnergyUJ + cur.EnergyUJ
+	default:
+		return 0, false
+	}
+	return float64(delta) / 1e6 / elapsed.Seconds(), true
+}
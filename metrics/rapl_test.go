@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadRAPLZones(t *testing.T) {
+	zones, err := ReadRAPLZones(filepath.Join("testdata", "powercap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The control type and the MMIO mirror are skipped.
+	if len(zones) != 2 {
+		t.Fatalf("got %d zones, want 2", len(zones))
+	}
+	pkg, dram := zones[0], zones[1]
+	if pkg.ID != "intel-rapl:0" || !pkg.IsPackage() || pkg.EnergyUJ != 5000000 || pkg.MaxEnergyUJ != 262143328850 {
+		t.Errorf("package zone = %+v", pkg)
+	}
+	if dram.ID != "intel-rapl:0:0" || !dram.IsDRAM() || dram.MaxEnergyUJ != 0 {
+		t.Errorf("dram zone = %+v", dram)
+	}
+}
+
+func TestRAPLWatts(t *testing.T) {
+	zone := func(energy, max uint64) *RAPLZone {
+		return &RAPLZone{ID: "intel-rapl:0", EnergyUJ: energy, MaxEnergyUJ: max}
+	}
+	tests := []struct {
+		name      string
+		prev, cur *RAPLZone
+		elapsed   time.Duration
+		want      float64
+		ok        bool
+	}{
+		{"steady", zone(1000000, 10000000), zone(21000000, 10000000), 2 * time.Second, 10, true},
+		{"wraparound", zone(9000000, 10000000), zone(1000000, 10000000), time.Second, 2, true},
+		{"reset without range", zone(9000000, 0), zone(1000000, 0), time.Second, 0, false},
+		{"reset below range", zone(9000000, 5000000), zone(1000000, 5000000), time.Second, 0, false},
+		{"no elapsed time", zone(0, 0), zone(1000000, 0), 0, 0, false},
+		{"no previous reading", nil, zone(1000000, 0), time.Second, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := RAPLWatts(tt.prev, tt.cur, tt.elapsed)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: RAPLWatts = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// SysfsRoot is where sysfs is mounted. Overriding it points every sysfs
+// reader in this package at a fixture tree instead of the live system.
+var SysfsRoot = "/sys"
+
+type SensorKind int
+
+const (
+	SensorTemperature SensorKind = iota
+	SensorFan
+	SensorVoltage
+	SensorCurrent
+	SensorPower
+)
+
+func (k SensorKind) String() string {
+	switch k {
+	case SensorFan:
+		return "fan"
+	case SensorVoltage:
+		return "voltage"
+	case SensorCurrent:
+		return "current"
+	case SensorPower:
+		return "power"
+	default:
+		return "temperature"
+	}
+}
+
+func (k SensorKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *SensorKind) UnmarshalText(text []byte) error {
+	for kind := SensorTemperature; kind <= SensorPower; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sensor kind %q", text)
+}
+
+func (k SensorKind) Unit() string {
+	switch k {
+	case SensorFan:
+		return "RPM"
+	case SensorVoltage:
+		return "V"
+	case SensorCurrent:
+		return "A"
+	case SensorPower:
+		return "W"
+	default:
+		return "°C"
+	}
+}
+
+type SensorReading struct {
+	Key      string     `json:"key"`
+	Label    string     `json:"label"`
+	Kind     SensorKind `json:"kind"`
+	Value    float64    `json:"value"`
+	Low      float64    `json:"low"`
+	High     float64    `json:"high"`
+	Critical float64    `json:"critical"`
+}
+
+type SensorChip struct {
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Readings []*SensorReading `json:"readings"`
+}
+
+// hwmonPrefixes maps sysfs attribute prefixes to their kind and the divisor
+// that converts the raw integer into the unit reported by SensorKind.Unit.
+var hwmonPrefixes = []struct {
+	prefix  string
+	kind    SensorKind
+	divisor float64
+}{
+	{"temp", SensorTemperature, 1000},
+	{"fan", SensorFan, 1},
+	{"in", SensorVoltage, 1000},
+	{"curr", SensorCurrent, 1000},
+	{"power", SensorPower, 1000000},
+}
+
+var cpuSensorChips = map[string]bool{
+	"coretemp":    true,
+	"k10temp":     true,
+	"k8temp":      true,
+	"zenpower":    true,
+	"cpu_thermal": true,
+	"cpu-thermal": true,
+}
+
+func GetSensors() ([]*SensorChip, error) {
+	chips, err := ReadHwmonSensors(filepath.Join(SysfsRoot, "class", "hwmon"))
+	if err == nil && len(chips) > 0 {
+		return chips, nil
+	}
+	return sensorsFromHost()
+}
+
+func ReadHwmonSensors(root string) ([]*SensorChip, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var chips []*SensorChip
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		chip := readHwmonChip(dir)
+		if chip == nil {
+			// Older drivers expose their attributes on the parent device.
+			chip = readHwmonChip(filepath.Join(dir, "device"))
+		}
+		if chip == nil {
+			continue
+		}
+		chip.ID = entry.Name()
+		if chip.Name == "" {
+			chip.Name = entry.Name()
+		}
+		chips = append(chips, chip)
+	}
+
+	sort.SliceStable(chips, func(i, j int) bool {
+		return naturalLess(chips[i].ID, chips[j].ID)
+	})
+	return chips, nil
+}
+
+func readHwmonChip(dir string) *SensorChip {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	chip := &SensorChip{Name: readSysfsString(filepath.Join(dir, "name"))}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, "_input") && !strings.HasSuffix(name, "_average") {
+			continue
+		}
+		key := name[:strings.LastIndexByte(name, '_')]
+		if strings.HasSuffix(name, "_average") && fileExists(filepath.Join(dir, key+"_input")) {
+			continue
+		}
+
+		kind, divisor, ok := hwmonKind(key)
+		if !ok {
+			continue
+		}
+		raw, ok := readSysfsFloat(filepath.Join(dir, name))
+		if !ok {
+			continue
+		}
+
+		reading := &SensorReading{
+			Key:   key,
+			Label: readSysfsString(filepath.Join(dir, key+"_label")),
+			Kind:  kind,
+			Value: raw / divisor,
+		}
+		if reading.Label == "" {
+			reading.Label = key
+		}
+		if v, ok := readSysfsFloat(filepath.Join(dir, key+"_min")); ok {
+			reading.Low = v / divisor
+		}
+		for _, suffix := range []string{"_max", "_cap"} {
+			if v, ok := readSysfsFloat(filepath.Join(dir, key+suffix)); ok && v > 0 {
+				reading.High = v / divisor
+				break
+			}
+		}
+		if v, ok := readSysfsFloat(filepath.Join(dir, key+"_crit")); ok && v > 0 {
+			reading.Critical = v / divisor
+		}
+		chip.Readings = append(chip.Readings, reading)
+	}
+
+	if len(chip.Readings) == 0 {
+		return nil
+	}
+	sort.SliceStable(chip.Readings, func(i, j int) bool {
+		a, b := chip.Readings[i], chip.Readings[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return naturalLess(a.Key, b.Key)
+	})
+	return chip
+}
+
+func hwmonKind(key string) (SensorKind, float64, bool) {
+	for _, p := range hwmonPrefixes {
+		if !strings.HasPrefix(key, p.prefix) {
+			continue
+		}
+		if _, err := strconv.Atoi(key[len(p.prefix):]); err != nil {
+			continue
+		}
+		return p.kind, p.divisor, true
+	}
+	return 0, 0, false
+}
+
+func sensorsFromHost() ([]*SensorChip, error) {
+	temps, err := host.SensorsTemperatures()
+	if len(temps) == 0 {
+		return nil, err
+	}
+	chip := &SensorChip{ID: "host", Name: "system"}
+	for _, t := range temps {
+		chip.Readings = append(chip.Readings, &SensorReading{
+			Key:      t.SensorKey,
+			Label:    t.SensorKey,
+			Kind:     SensorTemperature,
+			Value:    t.Temperature,
+			High:     t.High,
+			Critical: t.Critical,
+		})
+	}
+	return []*SensorChip{chip}, nil
+}
+
+// CPUTemperatures picks the temperature readings that belong to the CPU,
+// either from a known CPU driver or from a label that names a core.
+func CPUTemperatures(chips []*SensorChip) []*SensorReading {
+	var temps []*SensorReading
+	for _, chip := range chips {
+		cpuChip := cpuSensorChips[chip.Name]
+		for _, r := range chip.Readings {
+			if r.Kind != SensorTemperature {
+				continue
+			}
+			label := strings.ToLower(r.Label)
+			if cpuChip || strings.Contains(label, "cpu") || strings.Contains(label, "core") {
+				temps = append(temps, r)
+			}
+		}
+	}
+	return temps
+}
+
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readSysfsFloat(path string) (float64, bool) {
+	value := readSysfsString(path)
+	if value == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// naturalLess orders "temp2" before "temp10" and "hwmon2" before "hwmon10".
+func naturalLess(a, b string) bool {
+	ai := strings.IndexFunc(a, isDigit)
+	bi := strings.IndexFunc(b, isDigit)
+	if ai < 0 || bi < 0 || a[:ai] != b[:bi] {
+		return a < b
+	}
+	an, errA := strconv.Atoi(a[ai:])
+	bn, errB := strconv.Atoi(b[bi:])
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return an < bn
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadHwmonSensors(t *testing.T) {
+	chips, err := ReadHwmonSensors(filepath.Join("testdata", "hwmon"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, chip := range chips {
+		ids = append(ids, chip.ID)
+	}
+	// hwmon3 has no readings and is left out.
+	if want := []string{"hwmon1", "hwmon2", "hwmon10"}; !equalStrings(ids, want) {
+		t.Fatalf("chips = %v, want %v", ids, want)
+	}
+
+	// hwmon1 only has attributes on its parent device.
+	old := chips[0]
+	if old.Name != "acpitz" || len(old.Readings) != 2 {
+		t.Fatalf("device fallback: got %q with %d readings", old.Name, len(old.Readings))
+	}
+	if r := old.Readings[0]; r.Kind != SensorTemperature || r.Value != 27.8 {
+		t.Errorf("device fallback: first reading %+v", r)
+	}
+
+	core := chips[1]
+	var keys []string
+	for _, r := range core.Readings {
+		keys = append(keys, r.Key)
+	}
+	// Sorted by kind, then naturally by key.
+	if want := []string{"temp2", "temp10", "fan1", "power1", "power2"}; !equalStrings(keys, want) {
+		t.Fatalf("readings = %v, want %v", keys, want)
+	}
+	byKey := map[string]*SensorReading{}
+	for _, r := range core.Readings {
+		byKey[r.Key] = r
+	}
+
+	if r := byKey["temp2"]; r.Label != "Core 0" || r.Value != 45 || r.High != 80 || r.Critical != 100 {
+		t.Errorf("temp2 = %+v", r)
+	}
+	// Without a _label file the key is the label; a zero _max is no threshold.
+	if r := byKey["temp10"]; r.Label != "temp10" || r.High != 0 {
+		t.Errorf("temp10 = %+v", r)
+	}
+	if r := byKey["fan1"]; r.Value != 1200 || r.Low != 300 {
+		t.Errorf("fan1 = %+v", r)
+	}
+	// _average is used without _input, and _cap stands in for _max.
+	if r := byKey["power1"]; r.Value != 15 || r.High != 65 {
+		t.Errorf("power1 = %+v", r)
+	}
+	// _average is ignored when _input exists.
+	if r := byKey["power2"]; r.Value != 10 {
+		t.Errorf("power2 = %+v, want the _input value", r)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"hwmon2", "hwmon10", true},
+		{"hwmon10", "hwmon2", false},
+		{"temp2", "temp10", true},
+		{"temp1", "temp1", false},
+		{"fan1", "temp1", true},
+		{"temp", "temp1", true},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,20 +1,24 @@
 package types
 
 type ProcessInfo struct {
-	PID        int32
-	PPID       int32
-	Name       string
-	User       string
-	Priority   int32
-	Nice       int32
-	CPUPercent float64
-	Memory     uint64
-	MemPercent float32
-	VirtMem    uint64
-	ResMem     uint64
-	ShrMem     uint64
-	Status     string
-	Command    string
-	Threads    int32
-	CreateTime int64
+	PID        int32   `json:"pid"`
+	PPID       int32   `json:"ppid"`
+	Name       string  `json:"name"`
+	User       string  `json:"user"`
+	Priority   int32   `json:"priority"`
+	Nice       int32   `json:"nice"`
+	CPUPercent float64 `json:"cpuPercent"`
+	Memory     uint64  `json:"memory"`
+	MemPercent float32 `json:"memPercent"`
+	VirtMem    uint64  `json:"virtMem"`
+	ResMem     uint64  `json:"resMem"`
+	ShrMem     uint64  `json:"shrMem"`
+	PSS        uint64  `json:"pss"`
+	USS        uint64  `json:"uss"`
+	Swap       uint64  `json:"swap"`
+	MemDetail  bool    `json:"memDetail"`
+	Status     string  `json:"status"`
+	Command    string  `json:"command"`
+	Threads    int32   `json:"threads"`
+	CreateTime int64   `json:"createTime"`
 }
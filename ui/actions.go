@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const noticeDuration = 5 * time.Second
+
+// processAction is a signal waiting for the user to confirm with 'y'.
+type processAction struct {
+	pid    int32
+	name   string
+	signal string
+}
+
+// signalProcess sends SIGTERM or SIGKILL to pid. It refuses init and wtop
+// itself so a stray keypress cannot take down the machine or the agent.
+func signalProcess(pid int32, name string) error {
+	if pid <= 1 || int(pid) == os.Getpid() {
+		return fmt.Errorf("refusing to signal pid %d", pid)
+	}
+	var sig os.Signal
+	switch name {
+	case "TERM":
+		sig = syscall.SIGTERM
+	case "KILL":
+		sig = os.Kill
+	default:
+		return fmt.Errorf("unsupported signal %q", name)
+	}
+	p, err := os.FindProcess(int(pid))
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
+
+// requestSignal asks for confirmation before signalling the selected process.
+func (d *Dashboard) requestSignal(signal string) {
+	if d.replay != nil {
+		d.setNotice(warnTag() + "Process actions are not available in replay[-]")
+		return
+	}
+	row, _ := d.processTable.GetSelection()
+	if row <= 0 || row > len(d.tableProcs) {
+		return
+	}
+	proc := d.tableProcs[row-1]
+	d.pendingAction = &processAction{pid: proc.PID, name: proc.Name, signal: signal}
+	d.updateFooter(d.lastSnapshot, d.lastRates)
+}
+
+// requestSignalPID asks for confirmation before signalling pid, which need
+// not be in the process table.
+func (d *Dashboard) requestSignalPID(pid int32, signal string) error {
+	if d.replay != nil {
+		return fmt.Errorf("process actions are not available in replay")
+	}
+	name := "?"
+	if d.lastSnapshot != nil {
+		for _, p := range d.lastSnapshot.Processes {
+			if p.PID == pid {
+				name = p.Name
+				break
+			}
+		}
+	}
+	if name == "?" && d.localSource() {
+		if ok, _ := process.PidExists(pid); !ok {
+			return fmt.Errorf("no process with PID %d", pid)
+		}
+	}
+	d.pendingAction = &processAction{pid: pid, name: name, signal: signal}
+	d.updateFooter(d.lastSnapshot, d.lastRates)
+	return nil
+}
+
+// confirmAction handles the key pressed while an action is pending: 'y'
+// sends the signal, anything else cancels.
+func (d *Dashboard) confirmAction(confirmed bool) {
+	action := d.pendingAction
+	d.pendingAction = nil
+	if !confirmed {
+		d.setNotice("Cancelled")
+		return
+	}
+	if d.remote != nil {
+		if err := d.remote.sendAction(action.pid, action.signal); err != nil {
+			d.setNotice(fmt.Sprintf("%sSIG%s %s (%d): %v[-]", critTag(), action.signal, action.name, action.pid, err))
+		} else {
+			d.setNotice(fmt.Sprintf("Sent SIG%s to %s (%d) on the agent", action.signal, action.name, action.pid))
+		}
+		return
+	}
+	if err := signalProcess(action.pid, action.signal); err != nil {
+		d.setNotice(fmt.Sprintf("%sSIG%s %s (%d): %v[-]", critTag(), action.signal, action.name, action.pid, err))
+		return
+	}
+	d.setNotice(fmt.Sprintf("Sent SIG%s to %s (%d)", action.signal, action.name, action.pid))
+}
+
+func (d *Dashboard) setNotice(text string) {
+	d.notice = text
+	d.noticeAt = time.Now()
+	d.updateFooter(d.lastSnapshot, d.lastRates)
+}
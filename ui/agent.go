@@ -0,0 +1,301 @@
+package ui
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type AgentOptions struct {
+	Listen       string
+	Token        string
+	CertFile     string
+	KeyFile      string
+	Interval     time.Duration
+	AllowActions bool
+	// Insecure allows serving without TLS, which sends the token and the
+	// metrics in cleartext.
+	Insecure bool
+}
+
+// maxHandshakes bounds the connections that have not presented a valid
+// token yet; further ones are closed right away.
+const maxHandshakes = 32
+
+// agentHub fans the agent's snapshots out to every connected client. Each
+// client holds at most one pending snapshot, so a slow link skips stale
+// snapshots instead of queueing them.
+type agentHub struct {
+	mu      sync.Mutex
+	clients map[*agentClient]struct{}
+	latest  *snapshot
+}
+
+type agentClient struct {
+	fc   *frameConn
+	ch   chan *snapshot
+	done chan struct{}
+	once sync.Once
+}
+
+func (c *agentClient) offer(snap *snapshot) {
+	select {
+	case c.ch <- snap:
+	default:
+		select {
+		case <-c.ch:
+		default:
+		}
+		select {
+		case c.ch <- snap:
+		default:
+		}
+	}
+}
+
+func (c *agentClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.fc.Close()
+	})
+}
+
+func (h *agentHub) push(snap *snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest = snap
+	for c := range h.clients {
+		c.offer(snap)
+	}
+}
+
+func (h *agentHub) add(c *agentClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+	if h.latest != nil {
+		c.offer(h.latest)
+	}
+}
+
+func (h *agentHub) remove(c *agentClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+func (h *agentHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		c.close()
+	}
+}
+
+// RunAgent collects snapshots and streams them to `wtop connect` clients
+// until the process is interrupted.
+func RunAgent(opts AgentOptions) error {
+	if opts.Token == "" {
+		return fmt.Errorf("agent needs a token (--token or WTOP_TOKEN)")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = refreshInterval
+	}
+	if opts.CertFile == "" && opts.KeyFile == "" && !opts.Insecure {
+		return fmt.Errorf("agent needs --tls-cert and --tls-key, or --insecure to send the token unencrypted")
+	}
+
+	ln, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return err
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		ln = tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	} else {
+		log.Printf("wtop: agent: --insecure given; the token and metrics travel unencrypted")
+	}
+	log.Printf("wtop: agent listening on %s (process actions %s)", ln.Addr(), enabledWord(opts.AllowActions))
+
+	hub := &agentHub{clients: make(map[*agentClient]struct{})}
+	hostname, _ := os.Hostname()
+	welcome := welcomeMsg{
+		Version:  protocolVersion,
+		Hostname: hostname,
+		Interval: opts.Interval.Seconds(),
+		Actions:  opts.AllowActions,
+	}
+
+	stop := make(chan struct{})
+	handshakes := make(chan struct{}, maxHandshakes)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if errors.Is(err, net.ErrClosed) {
+					return
+				}
+				log.Printf("wtop: agent: accept: %v", err)
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			select {
+			case handshakes <- struct{}{}:
+			default:
+				log.Printf("wtop: agent: %s: too many pending handshakes", conn.RemoteAddr())
+				conn.Close()
+				continue
+			}
+			go serveAgentConn(conn, hub, opts, welcome, handshakes)
+		}
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	warmUp(defaultWarmup)
+	if snap, err := collectSnapshot(maxProcessEntries); err == nil {
+		hub.push(snap)
+	}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	collectEvery(ticker, stop, maxProcessEntries, func(snap *snapshot, err error) bool {
+		if err != nil {
+			log.Printf("wtop: collect: %v", err)
+			return true
+		}
+		hub.push(snap)
+		return true
+	})
+
+	ln.Close()
+	hub.closeAll()
+	return nil
+}
+
+// serveAgentConn authenticates a client and streams snapshots to it. It
+// holds a slot of handshakes until the handshake is over.
+func serveAgentConn(conn net.Conn, hub *agentHub, opts AgentOptions, welcome welcomeMsg, handshakes chan struct{}) {
+	fc := newFrameConn(conn)
+	peer := conn.RemoteAddr().String()
+
+	released := false
+	release := func() {
+		if !released {
+			released = true
+			<-handshakes
+		}
+	}
+	defer release()
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	var hello helloMsg
+	if err := fc.readHello(&hello); err != nil {
+		log.Printf("wtop: agent: %s: handshake: %v", peer, err)
+		fc.Close()
+		return
+	}
+	if hello.Version != protocolVersion {
+		fc.writeFrame(frameError, errorMsg{fmt.Sprintf("protocol version %d not supported (agent speaks %d)", hello.Version, protocolVersion)}, false)
+		fc.Close()
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hello.Token), []byte(opts.Token)) != 1 {
+		log.Printf("wtop: agent: %s: invalid token", peer)
+		// Slow down token guessing.
+		time.Sleep(time.Second)
+		fc.writeFrame(frameError, errorMsg{"invalid token"}, false)
+		fc.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	if err := fc.writeFrame(frameWelcome, welcome, false); err != nil {
+		fc.Close()
+		return
+	}
+	release()
+
+	log.Printf("wtop: agent: %s connected", peer)
+	client := &agentClient{fc: fc, ch: make(chan *snapshot, 1), done: make(chan struct{})}
+	hub.add(client)
+	defer func() {
+		hub.remove(client)
+		client.close()
+		log.Printf("wtop: agent: %s disconnected", peer)
+	}()
+
+	go func() {
+		defer client.close()
+		for {
+			kind, payload, err := fc.readFrame()
+			if err != nil {
+				return
+			}
+			if kind != frameAction {
+				continue
+			}
+			var action actionMsg
+			if err := json.Unmarshal(payload, &action); err != nil {
+				return
+			}
+			result := resultMsg{ID: action.ID}
+			if !opts.AllowActions {
+				result.Error = "process actions are disabled on this agent (--allow-actions)"
+			} else if err := signalProcess(action.PID, action.Signal); err != nil {
+				result.Error = err.Error()
+			}
+			log.Printf("wtop: agent: %s: SIG%s pid %d: %s", peer, action.Signal, action.PID, orOK(result.Error))
+			if fc.writeFrame(frameResult, result, false) != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-client.done:
+			return
+		case snap := <-client.ch:
+			if err := fc.writeFrame(frameSnapshot, snap, true); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func enabledWord(on bool) string {
+	if on {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func orOK(errText string) string {
+	if errText == "" {
+		return "ok"
+	}
+	return errText
+}
@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type BatchOptions struct {
+	Format   string
+	Fields   string
+	Interval time.Duration
+	Samples  int
+	TopN     int
+	Output   io.Writer
+}
+
+type systemRecord struct {
+	Timestamp       time.Time `json:"timestamp"`
+	Hostname        string    `json:"hostname"`
+	TotalCPU        float64   `json:"totalCpu"`
+	CPUPerCore      []float64 `json:"cpuPerCore"`
+	Load1           float64   `json:"load1"`
+	Load5           float64   `json:"load5"`
+	Load15          float64   `json:"load15"`
+	MemUsed         uint64    `json:"memUsed"`
+	MemTotal        uint64    `json:"memTotal"`
+	MemUsedPercent  float64   `json:"memUsedPercent"`
+	SwapUsed        uint64    `json:"swapUsed"`
+	SwapTotal       uint64    `json:"swapTotal"`
+	DiskUsedPercent float64   `json:"diskUsedPercent"`
+	NetUpBps        float64   `json:"netUpBps"`
+	NetDownBps      float64   `json:"netDownBps"`
+	GPUUtilization  []float64 `json:"gpuUtilization"`
+	GPUMemoryUsedMB []float64 `json:"gpuMemoryUsedMb"`
+}
+
+type processRecord struct {
+	PID        int32   `json:"pid"`
+	PPID       int32   `json:"ppid"`
+	User       string  `json:"user"`
+	Name       string  `json:"name"`
+	CPUPercent float64 `json:"cpuPercent"`
+	MemPercent float32 `json:"memPercent"`
+	RSS        uint64  `json:"rss"`
+	Threads    int32   `json:"threads"`
+	Status     string  `json:"status"`
+}
+
+type batchRecord struct {
+	Timestamp time.Time       `json:"timestamp"`
+	System    *systemRecord   `json:"system,omitempty"`
+	Processes []processRecord `json:"processes,omitempty"`
+}
+
+var systemCSVHeader = []string{
+	"timestamp", "hostname", "cpu_percent", "load1", "load5", "load15",
+	"mem_used_bytes", "mem_total_bytes", "mem_used_percent",
+	"swap_used_bytes", "swap_total_bytes", "disk_used_percent",
+	"net_up_bps", "net_down_bps", "gpu_util_max", "gpu_mem_used_mb",
+}
+
+var processCSVHeader = []string{
+	"timestamp", "pid", "ppid", "user", "name", "cpu_percent",
+	"mem_percent", "rss_bytes", "threads", "status",
+}
+
+// RunBatch writes one record per interval to opts.Output until the sample
+// limit is reached or the process is interrupted.
+func RunBatch(opts BatchOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = activeConfig.Refresh
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = 10
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	withSystem, withProcesses, err := parseBatchFields(opts.Fields)
+	if err != nil {
+		return err
+	}
+	if opts.Format == "csv" && withSystem && withProcesses {
+		return fmt.Errorf("csv output needs --fields system or --fields processes, not both")
+	}
+
+	out := bufio.NewWriter(opts.Output)
+	var write func(*batchRecord) error
+	switch opts.Format {
+	case "ndjson", "":
+		enc := json.NewEncoder(out)
+		write = func(rec *batchRecord) error {
+			return enc.Encode(rec)
+		}
+	case "csv":
+		w := csv.NewWriter(out)
+		if !hasContent(opts.Output) {
+			if withSystem {
+				w.Write(systemCSVHeader)
+			} else {
+				w.Write(processCSVHeader)
+			}
+		}
+		write = func(rec *batchRecord) error {
+			writeCSVRecord(w, rec)
+			w.Flush()
+			return w.Error()
+		}
+	default:
+		return fmt.Errorf("unsupported batch format %q", opts.Format)
+	}
+
+	// The priming snapshot is not written; it seeds the CPU and network
+	// counters so the first record covers a full interval.
+	prev, err := collectSnapshot(opts.TopN)
+	if err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	written := 0
+	var runErr error
+	collectEvery(ticker, stop, opts.TopN, func(snap *snapshot, err error) bool {
+		if err != nil {
+			runErr = err
+			return false
+		}
+		rec := &batchRecord{Timestamp: snap.Timestamp}
+		if withSystem {
+			rec.System = newSystemRecord(prev, snap)
+		}
+		if withProcesses {
+			rec.Processes = newProcessRecords(snap, opts.TopN)
+		}
+		prev = snap
+		if runErr = write(rec); runErr == nil {
+			runErr = out.Flush()
+		}
+		if runErr != nil {
+			return false
+		}
+		written++
+		return opts.Samples <= 0 || written < opts.Samples
+	})
+	if runErr != nil {
+		return runErr
+	}
+	return out.Flush()
+}
+
+// hasContent reports whether w is a file that already holds data, as when
+// --output appends to an earlier run; the CSV header is then not repeated.
+func hasContent(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode().IsRegular() && info.Size() > 0
+}
+
+func parseBatchFields(fields string) (system, processes bool, err error) {
+	switch fields {
+	case "", "system":
+		return true, false, nil
+	case "processes":
+		return false, true, nil
+	case "all":
+		return true, true, nil
+	}
+	return false, false, fmt.Errorf("unknown batch fields %q (want system, processes or all)", fields)
+}
+
+func newSystemRecord(prev, snap *snapshot) *systemRecord {
+	rec := &systemRecord{
+		Timestamp:  snap.Timestamp,
+		Hostname:   snap.Hostname,
+		TotalCPU:   snap.TotalCPU,
+		CPUPerCore: snap.CPUPerCore,
+		Load1:      snap.Load1,
+		Load5:      snap.Load5,
+		Load15:     snap.Load15,
+	}
+	if snap.Memory != nil {
+		rec.MemUsed = snap.Memory.Used
+		rec.MemTotal = snap.Memory.Total
+		rec.MemUsedPercent = snap.Memory.UsedPercent
+	}
+	if snap.Swap != nil {
+		rec.SwapUsed = snap.Swap.Used
+		rec.SwapTotal = snap.Swap.Total
+	}
+	if snap.Disk != nil {
+		rec.DiskUsedPercent = snap.Disk.UsedPercent
+	}
+	if prev != nil {
+		elapsed := snap.Timestamp.Sub(prev.Timestamp).Seconds()
+		rec.NetUpBps = counterRate(prev.NetBytesSent, snap.NetBytesSent, elapsed)
+		rec.NetDownBps = counterRate(prev.NetBytesRecv, snap.NetBytesRecv, elapsed)
+	}
+	for _, gpu := range snap.GPUInfos {
+		rec.GPUUtilization = append(rec.GPUUtilization, gpu.Utilization)
+		rec.GPUMemoryUsedMB = append(rec.GPUMemoryUsedMB, gpu.MemoryUsed)
+	}
+	return rec
+}
+
+func newProcessRecords(snap *snapshot, limit int) []processRecord {
+	procs := snap.Processes
+	if len(procs) > limit {
+		procs = procs[:limit]
+	}
+	records := make([]processRecord, 0, len(procs))
+	for _, p := range procs {
+		records = append(records, processRecord{
+			PID:        p.PID,
+			PPID:       p.PPID,
+			User:       p.User,
+			Name:       p.Name,
+			CPUPercent: p.CPUPercent,
+			MemPercent: p.MemPercent,
+			RSS:        p.ResMem,
+			Threads:    p.Threads,
+			Status:     p.Status,
+		})
+	}
+	return records
+}
+
+func writeCSVRecord(w *csv.Writer, rec *batchRecord) {
+	ts := rec.Timestamp.Format(time.RFC3339)
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	u := func(v uint64) string { return strconv.FormatUint(v, 10) }
+
+	if s := rec.System; s != nil {
+		gpuMax, gpuMem := 0.0, 0.0
+		for i, util := range s.GPUUtilization {
+			if util > gpuMax {
+				gpuMax = util
+			}
+			gpuMem += s.GPUMemoryUsedMB[i]
+		}
+		w.Write([]string{
+			ts, s.Hostname, f(s.TotalCPU), f(s.Load1), f(s.Load5), f(s.Load15),
+			u(s.MemUsed), u(s.MemTotal), f(s.MemUsedPercent),
+			u(s.SwapUsed), u(s.SwapTotal), f(s.DiskUsedPercent),
+			f(s.NetUpBps), f(s.NetDownBps), f(gpuMax), f(gpuMem),
+		})
+	}
+	for _, p := range rec.Processes {
+		w.Write([]string{
+			ts, strconv.Itoa(int(p.PID)), strconv.Itoa(int(p.PPID)), p.User, p.Name,
+			f(p.CPUPercent), f(float64(p.MemPercent)), u(p.RSS),
+			strconv.Itoa(int(p.Threads)), p.Status,
+		})
+	}
+}
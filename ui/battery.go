@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SwarnenduG07/wtop/metrics"
+)
+
+const batteryTrendWindow = 10 * time.Minute
+
+type batterySample struct {
+	at     time.Time
+	energy float64
+}
+
+// batteryTrend keeps recent energy readings so time estimates follow the
+// actual discharge slope instead of the jittery instantaneous power draw.
+type batteryTrend struct {
+	samples []batterySample
+	status  string
+}
+
+func (t *batteryTrend) Push(at time.Time, b *metrics.BatteryInfo) {
+	if b == nil || b.EnergyNow <= 0 {
+		t.samples = t.samples[:0]
+		return
+	}
+	if b.Status != t.status {
+		t.samples = t.samples[:0]
+		t.status = b.Status
+	}
+	t.samples = append(t.samples, batterySample{at: at, energy: b.EnergyNow})
+
+	cutoff := at.Add(-batteryTrendWindow)
+	drop := 0
+	for drop < len(t.samples)-2 && t.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		t.samples = append(t.samples[:0], t.samples[drop:]...)
+	}
+}
+
+// Watts returns the magnitude of the least-squares slope of energy over
+// time, in Wh per hour.
+func (t *batteryTrend) Watts() (float64, bool) {
+	if len(t.samples) < 3 {
+		return 0, false
+	}
+	origin := t.samples[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range t.samples {
+		x := s.at.Sub(origin).Hours()
+		sumX += x
+		sumY += s.energy
+		sumXY += x * s.energy
+		sumXX += x * x
+	}
+	n := float64(len(t.samples))
+	denom := n*sumXX - sumX*sumX
+	if denom <= 0 {
+		return 0, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denom
+	if slope < 0 {
+		slope = -slope
+	}
+	if slope < 0.05 {
+		return 0, false
+	}
+	return slope, true
+}
+
+func (d *Dashboard) batteryEstimate(b *metrics.BatteryInfo) time.Duration {
+	watts, ok := d.batteryTrend.Watts()
+	if !ok {
+		watts = b.PowerNow
+	}
+	switch {
+	case b.Discharging() && watts > 0:
+		return time.Duration(b.EnergyNow / watts * float64(time.Hour))
+	case b.Charging() && watts > 0 && b.EnergyFull > b.EnergyNow:
+		return time.Duration((b.EnergyFull - b.EnergyNow) / watts * float64(time.Hour))
+	case b.Discharging() && b.TimeToEmptyReport > 0:
+		return time.Duration(b.TimeToEmptyReport) * time.Second
+	case b.Charging() && b.TimeToFullReport > 0:
+		return time.Duration(b.TimeToFullReport) * time.Second
+	}
+	return 0
+}
+
+func (d *Dashboard) formatBattery(snap *snapshot) string {
+	if snap == nil || snap.Power == nil {
+		return ""
+	}
+	b := snap.Power.Battery()
+	if b == nil {
+		return ""
+	}
+
+	color := activeTheme.Good
+	switch {
+	case b.Capacity < 10:
+		color = activeTheme.Critical
+	case b.Capacity < 25:
+		color = activeTheme.Warning
+	}
+
+	parts := []string{
+		fmt.Sprintf("%s %s%.0f%%%s%s %s", glyphs.batteryLabel, colorTag(color), b.Capacity,
+			strings.TrimSpace(markLevel(b.Capacity < 25, b.Capacity < 10)), resetTag(), lowerStatus(b.Status)),
+	}
+	if b.PowerNow > 0 {
+		parts = append(parts, fmt.Sprintf("%.1fW", b.PowerNow))
+	}
+	if eta := d.batteryEstimate(b); eta > 0 {
+		if b.Charging() {
+			parts = append(parts, fmt.Sprintf("%s to full", formatUptime(eta)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s left", formatUptime(eta)))
+		}
+	}
+	if health := b.Health(); health > 0 {
+		parts = append(parts, fmt.Sprintf("health %.0f%%", health))
+	}
+	if snap.Power.ACPresent {
+		if snap.Power.ACOnline {
+			parts = append(parts, "AC on")
+		} else {
+			parts = append(parts, "AC off")
+		}
+	}
+	return joinWithSpacing(parts)
+}
+
+func lowerStatus(status string) string {
+	if status == "Not charging" {
+		return "idle"
+	}
+	return strings.ToLower(status)
+}
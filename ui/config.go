@@ -0,0 +1,491 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the user's preferences from config.yaml. Fields left out of
+// the file keep their built-in defaults.
+type Config struct {
+	Refresh    time.Duration   `yaml:"refresh"`
+	History    int             `yaml:"history"`
+	Sort       string          `yaml:"sort"`
+	Filter     string          `yaml:"filter"`
+	Columns    []string        `yaml:"columns"`
+	Thresholds ThresholdConfig `yaml:"thresholds"`
+	Units      UnitConfig      `yaml:"units"`
+	Names      []NameRule      `yaml:"names"`
+	Theme      string          `yaml:"theme"`
+	// Themes defines user themes as a base theme plus colors by role.
+	Themes map[string]map[string]string `yaml:"themes"`
+	// Colors is "auto" or forces the terminal's color depth to "truecolor",
+	// "256" or "16".
+	Colors string `yaml:"colors"`
+	Mouse  bool   `yaml:"mouse"`
+	// ASCII draws with plain ASCII only and spells out usage levels in text,
+	// for screen readers, serial consoles and fonts without block glyphs.
+	ASCII bool `yaml:"ascii"`
+	// GPU turns GPU collection off when false, which also saves spawning
+	// nvidia-smi on every refresh.
+	GPU   bool     `yaml:"gpu"`
+	Users []string `yaml:"users"`
+	// PIDs limits the process table to these processes. It is set from the
+	// command line only.
+	PIDs []int32 `yaml:"-"`
+	// Keys rebinds actions by name, replacing their default keys.
+	Keys map[string][]string `yaml:"keys"`
+
+	// PanelLayout is the layout used unless Layout names one of Layouts.
+	PanelLayout `yaml:",inline"`
+	Layouts     map[string]PanelLayout `yaml:"layouts"`
+	Layout      string                 `yaml:"layout"`
+
+	theme Theme
+	path  string
+}
+
+// PanelLayout arranges the dashboard: the panels shown, in order, on the
+// left with the process table on the right, their relative heights, and
+// the process table's share of the width in percent. A named layout leaves
+// out what it takes from the top-level one.
+type PanelLayout struct {
+	Panels       []string       `yaml:"panels,omitempty"`
+	Sizes        map[string]int `yaml:"sizes,omitempty"`
+	ProcessWidth int            `yaml:"process_width,omitempty"`
+}
+
+// ThresholdConfig sets the percentages at which usage turns yellow and red.
+type ThresholdConfig struct {
+	Warning  float64 `yaml:"warning"`
+	Critical float64 `yaml:"critical"`
+}
+
+type UnitConfig struct {
+	// Bytes is "binary" (1024, the default) or "decimal" (1000).
+	Bytes string `yaml:"bytes"`
+	// Temperature is "celsius" (the default) or "fahrenheit".
+	Temperature string `yaml:"temperature"`
+}
+
+// NameRule shows processes whose command line matches Match as Name, which
+// may refer to capture groups as $1 or ${name}.
+type NameRule struct {
+	Match string `yaml:"match"`
+	Name  string `yaml:"name"`
+
+	re *regexp.Regexp
+}
+
+var (
+	panelNames  = []string{"cpu", "memory", "disks", "gpu", "sensors", "processes"}
+	columnNames = []string{"pid", "user", "cpu", "mem", "pss", "uss", "swap", "gpu", "state", "threads", "priority", "nice", "virt", "res", "time", "command"}
+	sortNames   = map[string]SortMode{"cpu": SortByCPU, "mem": SortByMemory, "memory": SortByMemory, "time": SortByTime}
+)
+
+// SortNames lists the values accepted for the sort setting.
+func SortNames() []string { return []string{"cpu", "mem", "time"} }
+
+// ThemeNames lists the built-in themes; the config file may define more.
+func ThemeNames() []string { return append([]string(nil), themeNames...) }
+
+// PanelNames lists the panels that can be enabled.
+func PanelNames() []string { return append([]string(nil), panelNames...) }
+
+// ColumnNames lists the process table columns.
+func ColumnNames() []string { return append([]string(nil), columnNames...) }
+
+// goTypeName matches the Go type yaml.v3 names in unknown-field errors,
+// which means nothing to someone editing the file.
+var goTypeName = regexp.MustCompile(` in type ui\.\w+`)
+
+// DefaultConfig is what wtop uses without a config file.
+func DefaultConfig() *Config {
+	return &Config{
+		Refresh:    refreshInterval,
+		History:    historySize,
+		Sort:       "cpu",
+		Thresholds: ThresholdConfig{Warning: 65, Critical: 85},
+		Units:      UnitConfig{Bytes: "binary", Temperature: "celsius"},
+		Theme:      "dark",
+		Colors:     "auto",
+		Mouse:      true,
+		GPU:        true,
+		theme:      builtinThemes["dark"],
+		PanelLayout: PanelLayout{
+			Panels:       []string{"cpu", "memory", "disks", "gpu", "sensors", "processes"},
+			ProcessWidth: 50,
+		},
+	}
+}
+
+// activeConfig is the configuration the dashboard and the formatting helpers
+// read. It is replaced once at startup by UseConfig.
+var activeConfig = DefaultConfig()
+
+// UseConfig makes cfg and its theme the configuration for dashboards created
+// afterwards. cfg must have been returned by LoadConfig or DefaultConfig.
+func UseConfig(cfg *Config) {
+	activeConfig = cfg
+	useTheme(cfg.theme, cfg.Colors)
+	useGlyphs(cfg.ASCII)
+}
+
+// DefaultConfigPath returns $XDG_CONFIG_HOME/wtop/config.yaml, falling back
+// to the platform's user configuration directory.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wtop", "config.yaml")
+}
+
+// ConfigError lists every problem found in a config file or on the command
+// line so they can all be fixed in one go.
+type ConfigError struct {
+	Source   string
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s:\n  %s", e.Source, strings.Join(e.Problems, "\n  "))
+}
+
+// Validate reports problems in settings changed after loading, such as
+// command-line overrides, as a ConfigError naming source.
+func (c *Config) Validate(source string) error {
+	if problems := c.validate(); len(problems) > 0 {
+		return &ConfigError{Source: source, Problems: problems}
+	}
+	return nil
+}
+
+// LoadConfig reads the config file at path. A missing file yields the
+// defaults unless required is set, as it is for an explicit --config.
+func LoadConfig(path string, required bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		cfg := DefaultConfig()
+		cfg.path = path
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.path = path
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && err != io.EOF {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			problems := make([]string, len(typeErr.Errors))
+			for i, msg := range typeErr.Errors {
+				problems[i] = goTypeName.ReplaceAllString(msg, "")
+			}
+			return nil, &ConfigError{Source: "config " + path, Problems: problems}
+		}
+		return nil, &ConfigError{Source: "config " + path, Problems: []string{strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+	if problems := cfg.validate(); len(problems) > 0 {
+		return nil, &ConfigError{Source: "config " + path, Problems: problems}
+	}
+	return cfg, nil
+}
+
+// saveLayout writes layout to the config file c was loaded from, as the
+// top-level layout or, when name is set, under layouts. The rest of the
+// file, comments included, is kept as it is.
+func (c *Config) saveLayout(name string, layout PanelLayout) error {
+	if c.path == "" {
+		return errors.New("config: no config file path")
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("config: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("config %s: %w", c.path, err)
+	}
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	target := doc.Content[0]
+	if target.Kind != yaml.MappingNode {
+		return fmt.Errorf("config %s: top level is not a mapping", c.path)
+	}
+	if name != "" {
+		target = yamlMapping(yamlMapping(target, "layouts"), name)
+	}
+
+	var fields yaml.Node
+	if err := fields.Encode(layout); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		value := fields.Content[i+1]
+		value.Style = yaml.FlowStyle
+		setYAMLValue(target, fields.Content[i].Value, value)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	if err := os.WriteFile(c.path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	// The dashboard keeps editing its own layout in place, so the config
+	// gets copies to restore from.
+	layout.Panels = append([]string(nil), layout.Panels...)
+	sizes := make(map[string]int, len(layout.Sizes))
+	for panel, size := range layout.Sizes {
+		sizes[panel] = size
+	}
+	layout.Sizes = sizes
+	if name == "" {
+		c.PanelLayout = layout
+	} else {
+		if c.Layouts == nil {
+			c.Layouts = map[string]PanelLayout{}
+		}
+		c.Layouts[name] = layout
+	}
+	return nil
+}
+
+// yamlMapping returns the mapping under key in m, adding an empty one if
+// the key is missing or null.
+func yamlMapping(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key && m.Content[i+1].Kind == yaml.MappingNode {
+			return m.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	setYAMLValue(m, key, value)
+	return value
+}
+
+func setYAMLValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Refresh < 250*time.Millisecond || c.Refresh > time.Hour {
+		add("refresh: %s is outside 250ms..1h", c.Refresh)
+	}
+	if c.History < 10 || c.History > 10000 {
+		add("history: %d samples is outside 10..10000", c.History)
+	}
+	if _, ok := sortNames[strings.ToLower(c.Sort)]; !ok {
+		add("sort: unknown mode %q (want cpu, mem or time)", c.Sort)
+	}
+	if len(c.Panels) == 0 {
+		add("panels: at least one panel must be enabled")
+	}
+	if c.ProcessWidth == 0 {
+		add("process_width: 0%% is outside %d..%d", minProcessWidth, maxProcessWidth)
+	}
+	problems = append(problems, c.PanelLayout.validate("")...)
+	for name, layout := range c.Layouts {
+		problems = append(problems, layout.validate("layouts."+name+".")...)
+	}
+	if _, ok := c.Layouts[c.Layout]; c.Layout != "" && !ok {
+		add("layout: no layout named %q under layouts", c.Layout)
+	}
+	problems = append(problems, validateNames("columns", c.Columns, columnNames)...)
+	_, keyProblems := buildKeymap(c.Keys)
+	problems = append(problems, keyProblems...)
+
+	theme, themeProblems := c.resolveTheme()
+	problems = append(problems, themeProblems...)
+	c.theme = theme
+	switch c.Colors {
+	case "auto", "truecolor", "256", "16":
+	default:
+		add("colors: %q is not auto, truecolor, 256 or 16", c.Colors)
+	}
+	for i, pid := range c.PIDs {
+		if pid <= 0 {
+			add("pids[%d]: %d is not a valid PID", i, pid)
+		}
+	}
+
+	t := c.Thresholds
+	if t.Warning <= 0 || t.Critical > 100 || t.Warning >= t.Critical {
+		add("thresholds: need 0 < warning (%g) < critical (%g) <= 100", t.Warning, t.Critical)
+	}
+	switch c.Units.Bytes {
+	case "binary", "decimal":
+	default:
+		add("units.bytes: %q is not binary or decimal", c.Units.Bytes)
+	}
+	switch c.Units.Temperature {
+	case "celsius", "fahrenheit":
+	default:
+		add("units.temperature: %q is not celsius or fahrenheit", c.Units.Temperature)
+	}
+
+	for i := range c.Names {
+		rule := &c.Names[i]
+		re, err := regexp.Compile(rule.Match)
+		switch {
+		case rule.Match == "":
+			add("names[%d]: match is empty", i)
+		case err != nil:
+			add("names[%d]: %v", i, err)
+		case rule.Name == "":
+			add("names[%d]: name is empty", i)
+		default:
+			rule.re = re
+		}
+	}
+	return problems
+}
+
+// validate checks l, naming fields after prefix. Unset fields are allowed
+// here since named layouts inherit them.
+func (l PanelLayout) validate(prefix string) []string {
+	problems := validateNames(prefix+"panels", l.Panels, panelNames)
+	for name, size := range l.Sizes {
+		switch {
+		case name == "processes":
+			problems = append(problems, fmt.Sprintf("%ssizes.processes: the process table is sized by process_width", prefix))
+		case !containsString(panelNames, name):
+			problems = append(problems, fmt.Sprintf("%ssizes: unknown panel %q", prefix, name))
+		case size < 1 || size > maxPanelSize:
+			problems = append(problems, fmt.Sprintf("%ssizes.%s: %d is outside 1..%d", prefix, name, size, maxPanelSize))
+		}
+	}
+	if w := l.ProcessWidth; w != 0 && (w < minProcessWidth || w > maxProcessWidth) {
+		problems = append(problems, fmt.Sprintf("%sprocess_width: %d%% is outside %d..%d", prefix, w, minProcessWidth, maxProcessWidth))
+	}
+	return problems
+}
+
+// layout returns the named layout with the fields it leaves out filled in
+// from the top-level one, or the top-level layout for "".
+func (c *Config) layout(name string) PanelLayout {
+	l := c.PanelLayout
+	named, ok := c.Layouts[name]
+	if !ok {
+		return l
+	}
+	if len(named.Panels) > 0 {
+		l.Panels = named.Panels
+	}
+	if named.Sizes != nil {
+		l.Sizes = named.Sizes
+	}
+	if named.ProcessWidth != 0 {
+		l.ProcessWidth = named.ProcessWidth
+	}
+	return l
+}
+
+// LayoutNames lists the layouts defined in the config file, sorted.
+func (c *Config) LayoutNames() []string {
+	names := make([]string, 0, len(c.Layouts))
+	for name := range c.Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateNames(field string, values, known []string) []string {
+	var problems []string
+	seen := map[string]bool{}
+	for i, v := range values {
+		switch {
+		case !containsString(known, v):
+			problems = append(problems, fmt.Sprintf("%s[%d]: unknown %q (want one of %s)", field, i, v, strings.Join(known, ", ")))
+		case seen[v]:
+			problems = append(problems, fmt.Sprintf("%s[%d]: %q is listed twice", field, i, v))
+		}
+		seen[v] = true
+	}
+	return problems
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (c *Config) sortMode() SortMode {
+	return sortNames[strings.ToLower(c.Sort)]
+}
+
+// showsProcess applies the --pid and users restrictions.
+func (c *Config) showsProcess(pid int32, user string) bool {
+	if len(c.PIDs) > 0 {
+		found := false
+		for _, p := range c.PIDs {
+			found = found || p == pid
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(c.Users) == 0 || containsString(c.Users, user)
+}
+
+// displayName applies the first matching naming rule to a process command.
+func (c *Config) displayName(command string) string {
+	for _, rule := range c.Names {
+		if rule.re == nil {
+			continue
+		}
+		if m := rule.re.FindStringSubmatchIndex(command); m != nil {
+			return string(rule.re.ExpandString(nil, rule.Name, command, m))
+		}
+	}
+	return command
+}
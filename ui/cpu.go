@@ -4,13 +4,11 @@ import (
 	"fmt"
 	"math"
 	"strings"
-
-	"github.com/gdamore/tcell/v2"
 )
 
 func (d *Dashboard) updateCPU(snap *snapshot) {
 	if snap == nil || len(snap.CPUPerCore) == 0 {
-		d.cpuView.SetText("[yellow]CPU metrics unavailable[-]")
+		d.cpuView.SetText(warnTag() + "CPU metrics unavailable[-]")
 		return
 	}
 
@@ -23,7 +21,7 @@ func (d *Dashboard) updateCPU(snap *snapshot) {
 	totalSpark := ""
 	sparkWidth := clampInt(width-totalBarWidth-12, 8, 40)
 	if d.cpuHistory != nil && sparkWidth >= 8 {
-		totalSpark = "  " + renderSparkline(d.cpuHistory.Series(), sparkWidth)
+		totalSpark = "  " + renderSparkline(d.sparkSeries("cpu", d.cpuHistory), sparkWidth)
 	}
 	totalLine := fmt.Sprintf("Total %s%s", totalBar, totalSpark)
 
@@ -39,13 +37,23 @@ func (d *Dashboard) updateCPU(snap *snapshot) {
 		lines = append(lines, loadLine)
 	}
 
+	if snap.Pressure != nil {
+		if line := renderPressureLine("cpu", snap.Pressure.CPU, d.sparkSeries("psi.cpu", d.psiCPUHistory), sparkWidth); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if power := d.formatCPUPower(); power != "" {
+		lines = append(lines, power)
+	}
+
 	if len(snap.CPUTemp) > 0 {
 		tempStr := "Temp:"
 		for i, t := range snap.CPUTemp {
 			if i > 0 {
 				tempStr += ","
 			}
-			tempStr += fmt.Sprintf(" %.1f°C", t)
+			tempStr += fmt.Sprintf(" %s %s%s%s%s", t.Label, sensorMarker(t), colorTag(sensorColor(t)), formatTemperature(t.Value, 1), resetTag())
 		}
 		lines = append(lines, tempStr)
 	}
@@ -60,7 +68,7 @@ func (d *Dashboard) updateCPU(snap *snapshot) {
 			if builder.Len() > 0 {
 				builder.WriteString("  ")
 			}
-			label := fmt.Sprintf("%sC%02d%s", colorTag(tcell.ColorLightCyan), idx+1, resetTag())
+			label := fmt.Sprintf("%sC%02d%s", accentTag(), idx+1, resetTag())
 			builder.WriteString(label)
 			builder.WriteByte(' ')
 			builder.WriteString(renderUsageBar(cores[idx], barWidth))
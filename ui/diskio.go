@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diskRates is one block device's throughput, operations per second and
+// the share of time it was busy.
+type diskRates struct {
+	Read, Write       float64
+	ReadOps, WriteOps float64
+	Busy              float64
+}
+
+func (d *Dashboard) updateDiskIO(snap *snapshot) {
+	_, _, width, _ := d.diskIOView.GetInnerRect()
+	if width <= 0 {
+		width = 80
+	}
+	sparkWidth := clampInt(width-30, 8, 80)
+	barWidth := clampInt(width/4, 10, 30)
+
+	var lines []string
+	if snap == nil || len(snap.DiskIO) == 0 {
+		lines = append(lines, mutedTag()+"No per-device I/O counters[-]")
+	} else {
+		for _, c := range snap.DiskIO {
+			rates, ok := d.diskRates[c.Name]
+			header := fmt.Sprintf("%s%s%s", accentTag(), c.Name, resetTag())
+			if ok {
+				header += fmt.Sprintf("  busy %s  %.0f reads/s  %.0f writes/s",
+					renderUsageBar(rates.Busy, barWidth), rates.ReadOps, rates.WriteOps)
+			}
+			header += fmt.Sprintf("  %stotal %s read  %s written[-]",
+				mutedTag(), formatBytes(float64(c.ReadBytes)), formatBytes(float64(c.WriteBytes)))
+			hist := d.diskIOHistory[c.Name]
+			lines = append(lines, header,
+				fmt.Sprintf("  R %10s  %s", formatRate(rates.Read, ok), renderSparkline(hist.series(true), sparkWidth)),
+				fmt.Sprintf("  W %10s  %s", formatRate(rates.Write, ok), renderSparkline(hist.series(false), sparkWidth)))
+		}
+	}
+
+	if snap != nil && len(snap.Disks) > 0 {
+		lines = append(lines, "", accentTag()+"Filesystems[-]")
+		for _, u := range snap.Disks {
+			lines = append(lines, fmt.Sprintf("  %s %s  %s/%s  %s%s[-]",
+				padLabel(u.Path, 20),
+				renderUsageBar(u.UsedPercent, barWidth),
+				formatBytes(float64(u.Used)), formatBytes(float64(u.Total)),
+				mutedTag(), u.Fstype))
+		}
+	}
+	d.diskIOView.SetText(strings.Join(lines, "\n"))
+}
@@ -9,7 +9,16 @@ import (
 	"github.com/gdamore/tcell/v2"
 )
 
+// colorTag returns the tview tag for color as the terminal can show it. The
+// 16 basic colors are named so that the terminal's own palette is used.
 func colorTag(color tcell.Color) string {
+	color = fitColor(color)
+	switch {
+	case !color.Valid():
+		return "[-]"
+	case colorDepth < 1<<24 && !color.IsRGB() && color-tcell.ColorValid < 16:
+		return "[" + basicColorNames[color-tcell.ColorValid] + "]"
+	}
 	r, g, b := color.RGB()
 	return fmt.Sprintf("[#%02x%02x%02x]", r, g, b)
 }
@@ -20,12 +29,12 @@ func resetTag() string {
 
 func usageColor(percent float64) tcell.Color {
 	switch {
-	case percent >= 85:
-		return tcell.ColorIndianRed
-	case percent >= 65:
-		return tcell.ColorYellow
+	case percent >= activeConfig.Thresholds.Critical:
+		return activeTheme.Critical
+	case percent >= activeConfig.Thresholds.Warning:
+		return activeTheme.Warning
 	default:
-		return tcell.ColorGreen
+		return activeTheme.Good
 	}
 }
 
@@ -48,25 +57,25 @@ func renderUsageBar(percent float64, width int) string {
 
 	var b strings.Builder
 	fillColor := usageColor(percent)
-	b.WriteString(colorTag(tcell.ColorDarkSlateGray))
+	b.WriteString(colorTag(activeTheme.Border))
 	b.WriteRune('[')
 	b.WriteString(resetTag())
 	for i := 0; i < width; i++ {
 		if i < filled {
 			b.WriteString(colorTag(fillColor))
-			b.WriteRune('█')
+			b.WriteRune(glyphs.barFill)
 		} else {
-			b.WriteString(colorTag(tcell.ColorGray))
-			b.WriteRune(' ')
+			b.WriteString(mutedTag())
+			b.WriteRune(glyphs.barEmpty)
 		}
 	}
 	b.WriteString(resetTag())
-	b.WriteString(colorTag(tcell.ColorDarkSlateGray))
+	b.WriteString(colorTag(activeTheme.Border))
 	b.WriteRune(']')
 	b.WriteString(resetTag())
 	b.WriteRune(' ')
 	b.WriteString(colorTag(fillColor))
-	b.WriteString(fmt.Sprintf("%5.1f%%", percent))
+	b.WriteString(fmt.Sprintf("%5.1f%%%s", percent, levelMarker(percent)))
 	b.WriteString(resetTag())
 	return b.String()
 }
@@ -108,7 +117,7 @@ func renderSparkline(series []float64, width int) string {
 		return ""
 	}
 	if len(series) == 0 {
-		return colorTag(tcell.ColorGray) + strings.Repeat("·", width) + resetTag()
+		return mutedTag() + strings.Repeat(string(glyphs.sparkNone), width) + resetTag()
 	}
 
 	maxVal := 0.0
@@ -118,10 +127,10 @@ func renderSparkline(series []float64, width int) string {
 		}
 	}
 	if maxVal <= 0 {
-		return colorTag(tcell.ColorGray) + strings.Repeat("·", width) + resetTag()
+		return mutedTag() + strings.Repeat(string(glyphs.sparkNone), width) + resetTag()
 	}
 
-	blocks := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	blocks := glyphs.sparks
 
 	var samples []float64
 	if len(series) > width {
@@ -159,8 +168,8 @@ func renderSparkline(series []float64, width int) string {
 	var b strings.Builder
 	for _, v := range samples {
 		if v <= 0 {
-			b.WriteString(colorTag(tcell.ColorGray))
-			b.WriteRune('·')
+			b.WriteString(mutedTag())
+			b.WriteRune(glyphs.sparkNone)
 			continue
 		}
 		percent := 100 * (v / maxVal)
@@ -180,9 +189,14 @@ func renderSparkline(series []float64, width int) string {
 
 func formatBytes(value float64) string {
 	units := []string{"B", "K", "M", "G", "T", "P"}
+	base := 1024.0
+	if activeConfig.Units.Bytes == "decimal" {
+		units = []string{"B", "kB", "MB", "GB", "TB", "PB"}
+		base = 1000
+	}
 	idx := 0
-	for value >= 1024 && idx < len(units)-1 {
-		value /= 1024
+	for value >= base && idx < len(units)-1 {
+		value /= base
 		idx++
 	}
 	if value >= 100 || idx == 0 {
@@ -191,6 +205,22 @@ func formatBytes(value float64) string {
 	return fmt.Sprintf("%.1f%s", value, units[idx])
 }
 
+// formatTemperature formats a reading in degrees Celsius in the configured
+// unit.
+func formatTemperature(celsius float64, precision int) string {
+	if activeConfig.Units.Temperature == "fahrenheit" {
+		return fmt.Sprintf("%.*f%sF", precision, convertTemperature(celsius), glyphs.degree)
+	}
+	return fmt.Sprintf("%.*f%sC", precision, celsius, glyphs.degree)
+}
+
+func convertTemperature(celsius float64) float64 {
+	if activeConfig.Units.Temperature == "fahrenheit" {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
 func formatBytesPerSec(value float64) string {
 	if value <= 0 {
 		return "0B/s"
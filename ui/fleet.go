@@ -0,0 +1,446 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	fleetSparkWidth   = 8
+	fleetHistorySize  = 60
+	fleetRedrawPeriod = time.Second
+)
+
+// FleetHost is one line of a host list: an agent address and an optional
+// display name.
+type FleetHost struct {
+	Addr  string
+	Label string
+}
+
+type FleetOptions struct {
+	Hosts    []FleetHost
+	Token    string
+	TLS      bool
+	CAFile   string
+	Insecure bool
+}
+
+// ParseHostList reads "host:port [label]" lines, skipping blanks and #
+// comments.
+func ParseHostList(r io.Reader) ([]FleetHost, error) {
+	var hosts []FleetHost
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.Contains(fields[0], ":") {
+			return nil, fmt.Errorf("line %d: %q is not host:port", n, fields[0])
+		}
+		host := FleetHost{Addr: fields[0], Label: strings.Join(fields[1:], " ")}
+		if host.Label == "" {
+			host.Label = host.Addr
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, scanner.Err()
+}
+
+// fleetHost is the fleet view's state for one agent. The connection loop
+// updates it; the view reads it on every redraw.
+type fleetHost struct {
+	FleetHost
+	client *remoteClient
+
+	mu     sync.Mutex
+	last   *snapshot
+	lastAt time.Time
+	rates  netRates
+	// viewer receives the connection's frames while the host is open in
+	// the full dashboard.
+	viewer *remoteHandler
+
+	cpu, mem, disk, net, gpu *sparkHistory
+}
+
+func (h *fleetHost) update(snap *snapshot, first bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rates = netRates{}
+	if !first && h.last != nil {
+		if elapsed := snap.Timestamp.Sub(h.last.Timestamp).Seconds(); elapsed > 0 {
+			h.rates = netRates{
+				Up:    counterRate(h.last.NetBytesSent, snap.NetBytesSent, elapsed),
+				Down:  counterRate(h.last.NetBytesRecv, snap.NetBytesRecv, elapsed),
+				Valid: true,
+			}
+		}
+	}
+	h.last, h.lastAt = snap, time.Now()
+
+	h.cpu.Push(snap.TotalCPU)
+	if snap.Memory != nil {
+		h.mem.Push(snap.Memory.UsedPercent)
+	}
+	if snap.Disk != nil {
+		h.disk.Push(snap.Disk.UsedPercent)
+	}
+	if h.rates.Valid {
+		h.net.Push(h.rates.Up + h.rates.Down)
+	}
+	if util, ok := maxGPUUtilization(snap); ok {
+		h.gpu.Push(util)
+	}
+}
+
+func maxGPUUtilization(snap *snapshot) (float64, bool) {
+	if len(snap.GPUInfos) == 0 {
+		return 0, false
+	}
+	max := 0.0
+	for _, gpu := range snap.GPUInfos {
+		if gpu.Utilization > max {
+			max = gpu.Utilization
+		}
+	}
+	return max, true
+}
+
+type fleetState int
+
+const (
+	fleetUp fleetState = iota
+	fleetStale
+	fleetDown
+)
+
+func (h *fleetHost) state(now time.Time) fleetState {
+	if connected, _ := h.client.connection(); h.last == nil || !connected {
+		return fleetDown
+	}
+	interval := h.client.interval()
+	if interval <= 0 {
+		interval = refreshInterval
+	}
+	if now.Sub(h.lastAt) > 3*interval {
+		return fleetStale
+	}
+	return fleetUp
+}
+
+// health is the worst utilization across CPU, memory, disk and GPU, which
+// drives the color of the host name.
+func (h *fleetHost) health() float64 {
+	snap := h.last
+	worst := snap.TotalCPU
+	if snap.Memory != nil && snap.Memory.UsedPercent > worst {
+		worst = snap.Memory.UsedPercent
+	}
+	if snap.Disk != nil && snap.Disk.UsedPercent > worst {
+		worst = snap.Disk.UsedPercent
+	}
+	if util, ok := maxGPUUtilization(snap); ok && util > worst {
+		worst = util
+	}
+	return worst
+}
+
+type fleetSort int
+
+const (
+	fleetSortName fleetSort = iota
+	fleetSortCPU
+	fleetSortMem
+)
+
+func (s fleetSort) String() string {
+	switch s {
+	case fleetSortCPU:
+		return "CPU"
+	case fleetSortMem:
+		return "Mem"
+	default:
+		return "Name"
+	}
+}
+
+// RunFleet shows one row per agent and opens the full dashboard for the
+// selected host on Enter, returning to the fleet when it is closed.
+func RunFleet(opts FleetOptions) error {
+	if len(opts.Hosts) == 0 {
+		return fmt.Errorf("no hosts to monitor")
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var hosts []*fleetHost
+	for _, fh := range opts.Hosts {
+		ropts := RemoteOptions{Addr: fh.Addr, Token: opts.Token, TLS: opts.TLS, CAFile: opts.CAFile, Insecure: opts.Insecure}
+		client, err := newRemoteClient(ropts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fh.Addr, err)
+		}
+		h := &fleetHost{
+			FleetHost: fh,
+			client:    client,
+			cpu:       newSparkHistory(fleetHistorySize),
+			mem:       newSparkHistory(fleetHistorySize),
+			disk:      newSparkHistory(fleetHistorySize),
+			net:       newSparkHistory(fleetHistorySize),
+			gpu:       newSparkHistory(fleetHistorySize),
+		}
+		hosts = append(hosts, h)
+		go client.run(stop, remoteHandler{
+			snapshot: func(snap *snapshot, first bool) {
+				h.update(snap, first)
+				if v := h.attached(); v != nil {
+					v.snapshot(snap, first)
+				}
+			},
+			result: func(result resultMsg) {
+				if v := h.attached(); v != nil {
+					v.result(result)
+				}
+			},
+			changed: func() {
+				if v := h.attached(); v != nil {
+					v.changed()
+				}
+			},
+		})
+	}
+
+	view := &fleetView{hosts: hosts}
+	for {
+		selected, err := view.run()
+		if err != nil || selected == nil {
+			return err
+		}
+		if err := selected.open(); err != nil {
+			return err
+		}
+	}
+}
+
+// open shows the host in the full dashboard until it is closed. The
+// dashboard starts from the host's latest snapshot and is fed by the fleet's
+// connection instead of opening another one.
+func (h *fleetHost) open() error {
+	d := NewDashboard()
+	d.remote = h.client
+	d.remoteShared = true
+	if interval := h.client.interval(); interval > 0 {
+		d.refreshInterval = interval
+	}
+	h.mu.Lock()
+	last := h.last
+	h.mu.Unlock()
+	if last != nil {
+		d.applySnapshot(last, false)
+	}
+
+	events := d.remoteEvents()
+	h.attach(&events)
+	defer h.attach(nil)
+	return d.Run()
+}
+
+func (h *fleetHost) attach(viewer *remoteHandler) {
+	h.mu.Lock()
+	h.viewer = viewer
+	h.mu.Unlock()
+}
+
+func (h *fleetHost) attached() *remoteHandler {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.viewer
+}
+
+type fleetView struct {
+	hosts    []*fleetHost
+	sortMode fleetSort
+	order    []*fleetHost
+	selected int
+}
+
+// run shows the fleet table until the user quits (nil) or picks a host.
+func (v *fleetView) run() (*fleetHost, error) {
+	app := tview.NewApplication()
+	header := tview.NewTextView().SetDynamicColors(true)
+	header.SetBackgroundColor(activeTheme.Background)
+	footer := tview.NewTextView().SetDynamicColors(true)
+	footer.SetBackgroundColor(activeTheme.StatusBar)
+	footer.SetText("[::b]Enter[-] Open host  [::b]s[-] Sort  [::b]" + glyphs.keysUpDown + "[-] Select  [::b]q[-] Quit")
+
+	table := tview.NewTable().SetBorders(false).SetSelectable(true, false).SetFixed(1, 0)
+	table.SetBackgroundColor(activeTheme.Background)
+	table.SetBorder(true).SetTitle(" Fleet ").SetTitleColor(activeTheme.Accent)
+	table.SetBorderColor(activeTheme.Border)
+	table.SetSelectedStyle(activeTheme.selectedStyle())
+
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(header, 1, 0, false).
+		AddItem(table, 0, 1, true).
+		AddItem(footer, 1, 0, false)
+
+	var picked *fleetHost
+	table.SetSelectedFunc(func(row, _ int) {
+		if row > 0 && row <= len(v.order) {
+			picked = v.order[row-1]
+			app.Stop()
+		}
+	})
+	table.SetSelectionChangedFunc(func(row, _ int) {
+		if row > 0 {
+			v.selected = row - 1
+		}
+	})
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyCtrlC, event.Key() == tcell.KeyEscape,
+			event.Key() == tcell.KeyRune && (event.Rune() == 'q' || event.Rune() == 'Q'):
+			app.Stop()
+			return nil
+		case event.Key() == tcell.KeyRune && (event.Rune() == 's' || event.Rune() == 'S'):
+			v.sortMode = (v.sortMode + 1) % 3
+			v.render(header, table)
+			return nil
+		}
+		return event
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(fleetRedrawPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				app.QueueUpdateDraw(func() { v.render(header, table) })
+			}
+		}
+	}()
+
+	v.render(header, table)
+	table.Select(v.selected+1, 0)
+	err := app.SetRoot(root, true).EnableMouse(true).Run()
+	close(done)
+	return picked, err
+}
+
+func (v *fleetView) render(header *tview.TextView, table *tview.Table) {
+	now := time.Now()
+	v.order = append(v.order[:0], v.hosts...)
+	sortKey := func(h *fleetHost) float64 {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.last == nil {
+			return -1
+		}
+		if v.sortMode == fleetSortMem && h.last.Memory != nil {
+			return h.last.Memory.UsedPercent
+		}
+		return h.last.TotalCPU
+	}
+	if v.sortMode != fleetSortName {
+		sort.SliceStable(v.order, func(i, j int) bool { return sortKey(v.order[i]) > sortKey(v.order[j]) })
+	}
+
+	titles := []string{"HOST", "STATE", "CPU", "MEM", "DISK", "NET", "GPU", "LOAD", "UPTIME"}
+	table.Clear()
+	for col, title := range titles {
+		table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(activeTheme.Accent).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false))
+	}
+
+	counts := map[fleetState]int{}
+	for i, h := range v.order {
+		row := i + 1
+		h.mu.Lock()
+		state := h.state(now)
+		counts[state]++
+		cells := v.rowCells(h, state, now)
+		h.mu.Unlock()
+		for col, text := range cells {
+			align := tview.AlignLeft
+			if col >= 7 {
+				align = tview.AlignRight
+			}
+			table.SetCell(row, col, tview.NewTableCell(text).SetAlign(align))
+		}
+	}
+
+	header.SetText(fmt.Sprintf("[::b]%s FLEET%s  %d hosts  %s%d up[-]  %s%d stale[-]  %s%d down[-]  Sort %s",
+		accentTag(), resetTag(), len(v.hosts),
+		goodTag(), counts[fleetUp], warnTag(), counts[fleetStale], critTag(), counts[fleetDown], v.sortMode))
+}
+
+// rowCells formats one host; the caller holds h.mu.
+func (v *fleetView) rowCells(h *fleetHost, state fleetState, now time.Time) []string {
+	name := h.Label
+	switch state {
+	case fleetDown:
+		_, status := h.client.connection()
+		cells := []string{critTag() + name + "[-]", critTag() + glyphs.disconnected + " " + truncateLabel(status, 48) + "[-]"}
+		return append(cells, "", "", "", "", "", "", "")
+	case fleetStale:
+		name = warnTag() + name + "[-]"
+	default:
+		name = colorTag(usageColor(h.health())) + name + resetTag()
+	}
+
+	snap := h.last
+	percent := func(p float64, hist *sparkHistory) string {
+		return fmt.Sprintf("%s%5.1f%%%s%s %s", colorTag(usageColor(p)), p, levelMarker(p), resetTag(),
+			renderSparkline(hist.Series(), fleetSparkWidth))
+	}
+
+	age := now.Sub(h.lastAt).Truncate(time.Second)
+	stateCell := fmt.Sprintf("%s%s %s[-]", goodTag(), glyphs.connected, age)
+	if state == fleetStale {
+		stateCell = fmt.Sprintf("%s%s %s[-]", warnTag(), glyphs.stale, age)
+	}
+	cells := []string{name, stateCell, percent(snap.TotalCPU, h.cpu)}
+	if snap.Memory != nil {
+		cells = append(cells, percent(snap.Memory.UsedPercent, h.mem))
+	} else {
+		cells = append(cells, "-")
+	}
+	if snap.Disk != nil {
+		cells = append(cells, percent(snap.Disk.UsedPercent, h.disk))
+	} else {
+		cells = append(cells, "-")
+	}
+	if h.rates.Valid {
+		cells = append(cells, fmt.Sprintf("%s%s %s%s %s", glyphs.up, formatBytesPerSec(h.rates.Up), glyphs.down, formatBytesPerSec(h.rates.Down),
+			renderSparkline(h.net.Series(), fleetSparkWidth)))
+	} else {
+		cells = append(cells, "-")
+	}
+	if util, ok := maxGPUUtilization(snap); ok {
+		cells = append(cells, percent(util, h.gpu))
+	} else {
+		cells = append(cells, mutedTag()+"-[-]")
+	}
+	load := "-"
+	if snap.LoadReported {
+		load = fmt.Sprintf("%.2f", snap.Load1)
+	}
+	return append(cells, load, formatUptime(snap.Uptime))
+}
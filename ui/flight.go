@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SwarnenduG07/wtop/metrics"
+	"github.com/SwarnenduG07/wtop/types"
+)
+
+const defaultFlightWindow = 5 * time.Minute
+
+// FlightOptions configures the flight recorder. Zero-valued triggers are
+// disabled; a dump can always be requested by hand.
+type FlightOptions struct {
+	Window   time.Duration
+	Dir      string
+	Cooldown time.Duration
+
+	CPUAbove float64
+	CPUFor   time.Duration
+	MemBelow uint64
+	Watch    []string
+}
+
+// flightWatch follows the processes behind one --flight-watch target, given
+// either as a PID or as a command name.
+type flightWatch struct {
+	target string
+	fixed  bool
+	pids   []int32
+}
+
+// flightRecorder keeps the last Window of snapshots in memory and writes them
+// out as a replayable recording when a trigger fires. push and trigger run on
+// the collecting goroutine; only the dump itself happens in the background.
+type flightRecorder struct {
+	opts    FlightOptions
+	ring    []*snapshot
+	watches []*flightWatch
+
+	cpuHighSince time.Time
+	cpuFired     bool
+	memLow       bool
+	lastDump     time.Time
+	// pending is the reason of a trigger that fired during the cooldown;
+	// it is dumped once the cooldown is over.
+	pending string
+
+	// lastStamp and stampSeq keep dump names unique when two dumps fall in
+	// the same millisecond.
+	lastStamp string
+	stampSeq  int
+
+	logf func(format string, args ...any)
+
+	mu      sync.Mutex
+	status  string
+	lastErr error
+}
+
+func newFlightRecorder(opts FlightOptions) *flightRecorder {
+	if opts.Window <= 0 {
+		opts.Window = defaultFlightWindow
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = opts.Window
+	}
+	f := &flightRecorder{opts: opts}
+	for _, target := range opts.Watch {
+		w := &flightWatch{target: target}
+		if pid, err := strconv.ParseInt(target, 10, 32); err == nil {
+			w.fixed = true
+			w.pids = []int32{int32(pid)}
+		}
+		f.watches = append(f.watches, w)
+	}
+	return f
+}
+
+func (f *flightRecorder) push(snap *snapshot) {
+	if snap == nil {
+		return
+	}
+	f.ring = append(f.ring, snap)
+	cutoff := snap.Timestamp.Add(-f.opts.Window)
+	drop := 0
+	for drop < len(f.ring)-1 && f.ring[drop].Timestamp.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		f.ring = append(f.ring[:0:0], f.ring[drop:]...)
+	}
+
+	if reason := f.check(snap); reason != "" && f.pending == "" {
+		f.pending = reason
+	}
+	if f.pending != "" && snap.Timestamp.Sub(f.lastDump) >= f.opts.Cooldown {
+		reason := f.pending
+		f.pending = ""
+		f.trigger(reason, snap.Timestamp)
+	}
+}
+
+// check evaluates the automatic triggers against the newest snapshot. Each
+// trigger fires once when its condition starts and re-arms when it clears.
+func (f *flightRecorder) check(snap *snapshot) string {
+	reason := ""
+
+	if f.opts.CPUAbove > 0 {
+		if snap.TotalCPU >= f.opts.CPUAbove {
+			if f.cpuHighSince.IsZero() {
+				f.cpuHighSince = snap.Timestamp
+			}
+			if !f.cpuFired && snap.Timestamp.Sub(f.cpuHighSince) >= f.opts.CPUFor {
+				f.cpuFired = true
+				reason = fmt.Sprintf("cpu>=%.0f%% for %s", f.opts.CPUAbove, f.opts.CPUFor)
+			}
+		} else {
+			f.cpuHighSince = time.Time{}
+			f.cpuFired = false
+		}
+	}
+
+	if f.opts.MemBelow > 0 && snap.Memory != nil {
+		low := snap.Memory.Available < f.opts.MemBelow
+		if low && !f.memLow && reason == "" {
+			reason = "mem available<" + formatBytes(float64(f.opts.MemBelow))
+		}
+		f.memLow = low
+	}
+
+	for _, w := range f.watches {
+		if exited := w.poll(); exited && reason == "" {
+			reason = "exit " + w.target
+		}
+	}
+	return reason
+}
+
+// poll reports whether a previously seen process of the target has exited.
+// Name targets are resolved again once all of their processes are gone.
+func (w *flightWatch) poll() bool {
+	if !w.fixed && len(w.pids) == 0 {
+		w.pids = metrics.FindProcesses(w.target)
+		return false
+	}
+	alive := w.pids[:0]
+	for _, pid := range w.pids {
+		if metrics.ProcessExists(pid) {
+			alive = append(alive, pid)
+		}
+	}
+	exited := len(alive) < len(w.pids)
+	w.pids = alive
+	return exited
+}
+
+// trigger dumps the ring buffer to a new recording in the background.
+func (f *flightRecorder) trigger(reason string, at time.Time) {
+	if len(f.ring) == 0 {
+		return
+	}
+	f.lastDump = at
+	// The dashboard keeps filling in memory detail on the ring's processes,
+	// so the background writer gets its own copies.
+	frames := make([]*snapshot, len(f.ring))
+	for i, snap := range f.ring {
+		frames[i] = copySnapshot(snap)
+	}
+	stamp := at.Local().Format("20060102-150405.000")
+	if stamp == f.lastStamp {
+		f.stampSeq++
+		stamp += "-" + strconv.Itoa(f.stampSeq+1)
+	} else {
+		f.lastStamp, f.stampSeq = stamp, 0
+	}
+	name := fmt.Sprintf("wtop-flight-%s.wtop", stamp)
+	path := filepath.Join(f.opts.Dir, name)
+
+	f.setStatus(fmt.Sprintf("dumping %s (%s)", name, reason), nil)
+	go func() {
+		err := writeRecording(path, frames)
+		if err != nil {
+			f.setStatus("", err)
+		} else {
+			f.setStatus(fmt.Sprintf("dumped %s (%s)", name, reason), nil)
+		}
+		if f.logf != nil {
+			if err != nil {
+				f.logf("wtop: flight recorder: %v", err)
+			} else {
+				f.logf("wtop: flight recorder: wrote %s, %d snapshots (%s)", path, len(frames), reason)
+			}
+		}
+	}()
+}
+
+// copySnapshot returns a copy of snap with its own process list.
+func copySnapshot(snap *snapshot) *snapshot {
+	c := *snap
+	c.Processes = make([]*types.ProcessInfo, len(snap.Processes))
+	for i, p := range snap.Processes {
+		info := *p
+		c.Processes[i] = &info
+	}
+	return &c
+}
+
+func (f *flightRecorder) setStatus(status string, err error) {
+	f.mu.Lock()
+	f.status, f.lastErr = status, err
+	f.mu.Unlock()
+}
+
+func (f *flightRecorder) footerStatus() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lastErr != nil {
+		return fmt.Sprintf("%sflight: %v[-]", critTag(), f.lastErr)
+	}
+	if f.status != "" {
+		return warnTag() + "flight: " + f.status + "[-]"
+	}
+	return "Flight " + formatUptime(f.opts.Window)
+}
+
+// writeRecording saves frames as a complete recording, as if Record had
+// captured them.
+func writeRecording(path string, frames []*snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	header := recordingHeader{Hostname: frames[0].Hostname, Started: frames[0].Timestamp}
+	if n := len(frames); n > 1 {
+		header.Interval = frames[n-1].Timestamp.Sub(frames[0].Timestamp).Seconds() / float64(n-1)
+	}
+	w, err := createRecording(path, header)
+	if err != nil {
+		return err
+	}
+	for _, snap := range frames {
+		if err := w.write(snap); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// EnableFlightRecorder keeps recent snapshots in memory and dumps them when a
+// trigger fires or 'd' is pressed.
+func (d *Dashboard) EnableFlightRecorder(opts FlightOptions) {
+	d.flight = newFlightRecorder(opts)
+	d.sinks = append(d.sinks, d.flight)
+}
+
+func (d *Dashboard) dumpFlightRecorder() {
+	if d.flight == nil {
+		d.footer.SetText(warnTag() + "Flight recorder is off; start wtop with --flight[-]")
+		return
+	}
+	at := time.Now()
+	if d.lastSnapshot != nil {
+		at = d.lastSnapshot.Timestamp
+	}
+	d.flight.trigger("manual", at)
+	d.updateFooter(d.lastSnapshot, d.lastRates)
+}
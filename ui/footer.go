@@ -1,14 +1,61 @@
 package ui
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+)
 
 func (d *Dashboard) updateFooter(snap *snapshot, rates netRates) {
-	lineOne := "[::b]F1[-] Help  [::b]/[-] Filter  [::b]s[-] Sort  [::b]↑↓[-] Scroll  [::b]q[-] Quit"
+	km := d.keymap
+	hints := []string{
+		km.hint("help"), "Help",
+		km.hint("palette"), "Commands",
+		km.hint("filter"), "Filter",
+		km.hint("sort"), "Sort",
+		km.hint("mem_detail"), "Mem detail",
+		km.hint("pss"), "PSS",
+		km.hint("history_range"), "Range",
+		km.hint("terminate"), "Kill",
+		km.keyPair("tab_overview", "tab_sensors", "-", ""), "Tabs",
+		km.hint("next_panel"), "Panel",
+		km.hint("zoom"), "Zoom",
+		km.keyPair("up", "down", " ", glyphs.keysUpDown), "Scroll",
+	}
 
 	parts := []string{
 		fmt.Sprintf("Refresh %.0fs", d.refreshInterval.Seconds()),
 		fmt.Sprintf("Sort %s", d.sortMode.String()),
 	}
+	if d.flight != nil {
+		hints = append(hints, km.hint("dump"), "Dump")
+	}
+	if d.remote != nil {
+		parts[0] = d.remote.status()
+	}
+	if d.replay != nil {
+		hints = []string{
+			km.hint("replay_pause"), "Pause",
+			km.keyPair("replay_step", "replay_step_back", " ", ""), "Step",
+			km.keyPair("replay_back", "replay_forward", " ", glyphs.keysLeftRight), "Seek 30s",
+			km.keyPair("replay_faster", "replay_slower", " ", ""), "Speed",
+			km.keyPair("replay_start", "replay_end", " ", ""), "Jump",
+			km.hint("sort"), "Sort",
+		}
+		parts[0] = d.replay.status()
+	}
+
+	hints = append(hints, km.hint("quit"), "Quit")
+	lineOne := formatKeyHints(hints)
+
+	if d.history != nil {
+		parts = append(parts, "History "+historyRanges[d.historyRange].label)
+	}
+	if d.historyErr != nil {
+		parts = append(parts, fmt.Sprintf("%shistory: %v[-]", critTag(), d.historyErr))
+	}
 
 	if snap != nil {
 		parts = append(parts, fmt.Sprintf("Tasks %d", snap.ProcessSummary.Total))
@@ -22,9 +69,45 @@ func (d *Dashboard) updateFooter(snap *snapshot, rates netRates) {
 	}
 
 	if rates.Valid {
-		parts = append(parts, fmt.Sprintf("Net %s ↑ %s ↓", formatBytesPerSec(rates.Up), formatBytesPerSec(rates.Down)))
+		parts = append(parts, fmt.Sprintf("Net %s %s %s %s", formatBytesPerSec(rates.Up), glyphs.up, formatBytesPerSec(rates.Down), glyphs.down))
+	}
+
+	for _, sink := range d.sinks {
+		switch s := sink.(type) {
+		case *asyncSink:
+			if err := s.err(); err != nil {
+				parts = append(parts, fmt.Sprintf("%s%v[-]", critTag(), err))
+			}
+		case *flightRecorder:
+			parts = append(parts, s.footerStatus())
+		case *liveRecording:
+			parts = append(parts, s.footerStatus())
+		}
+	}
+
+	if d.notice != "" && time.Since(d.noticeAt) < noticeDuration {
+		parts = append(parts, d.notice)
+	}
+	if a := d.pendingAction; a != nil {
+		parts = append(parts, fmt.Sprintf("%s[::b]Send SIG%s to %s (%d)? y/n[-:-:-]", warnTag(), a.signal, a.name, a.pid))
 	}
 
 	lineTwo := joinWithSpacing(parts)
 	d.footer.SetText(lineOne + "\n" + lineTwo)
 }
+
+// formatKeyHints joins key and label pairs for the footer, leaving out
+// actions that are unbound.
+func formatKeyHints(hints []string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(hints); i += 2 {
+		if hints[i] == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("  ")
+		}
+		fmt.Fprintf(&b, "[::b]%s[-] %s", tview.Escape(hints[i]), hints[i+1])
+	}
+	return b.String()
+}
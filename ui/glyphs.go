@@ -0,0 +1,186 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+)
+
+// glyphSet holds every non-ASCII symbol the dashboard draws, so that
+// the accessible mode can swap them for plain ASCII that renders the same
+// in any font, over serial consoles and through screen readers.
+type glyphSet struct {
+	barFill, barEmpty rune
+	sparks            []rune // sparkline levels, lowest first
+	sparkNone         rune   // sparkline sample with no data
+	sep               string // separator in titles
+	up, down          string // network direction
+	connected         string // remote and fleet connection state
+	disconnected      string
+	stale             string
+	playing           string // replay state
+	paused            string
+	stopped           string
+	degree            string
+	keysUpDown        string // footer hint for the arrow keys
+	keysLeftRight     string
+
+	// Header labels. The emoji in the default set are two cells wide in most
+	// fonts.
+	cpuLabel, memLabel, swapLabel, diskLabel, netLabel, batteryLabel string
+}
+
+var unicodeGlyphs = glyphSet{
+	barFill:       '█',
+	barEmpty:      ' ',
+	sparks:        []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'},
+	sparkNone:     '·',
+	sep:           " · ",
+	up:            "↑",
+	down:          "↓",
+	connected:     "●",
+	disconnected:  "○",
+	stale:         "◌ stale",
+	playing:       "▶",
+	paused:        "⏸",
+	stopped:       "■",
+	degree:        "°",
+	keysUpDown:    "↑↓",
+	keysLeftRight: "←→",
+	cpuLabel:      "⚙ CPU",
+	memLabel:      "💾 MEM",
+	swapLabel:     "🔄 SWP",
+	diskLabel:     "📀 DISK",
+	netLabel:      "🌐",
+	batteryLabel:  "🔋 BAT",
+}
+
+var asciiGlyphs = glyphSet{
+	barFill:       '#',
+	barEmpty:      '.',
+	sparks:        []rune{'_', '.', ',', '-', '~', '=', '*', '#'},
+	sparkNone:     ' ',
+	sep:           " - ",
+	up:            "TX",
+	down:          "RX",
+	connected:     "up",
+	disconnected:  "down",
+	stale:         "stale",
+	playing:       "playing",
+	paused:        "paused",
+	stopped:       "ended",
+	degree:        "",
+	keysUpDown:    "Up/Down",
+	keysLeftRight: "Left/Right",
+	cpuLabel:      "CPU",
+	memLabel:      "MEM",
+	swapLabel:     "SWP",
+	diskLabel:     "DISK",
+	netLabel:      "NET",
+	batteryLabel:  "BAT",
+}
+
+// glyphs is the symbol set in use and textMarkers whether usage levels are
+// also spelled out in text rather than signaled by color alone. Both are set
+// by UseConfig.
+var (
+	glyphs      = &unicodeGlyphs
+	textMarkers = false
+)
+
+// useGlyphs switches between the default and the accessible rendering. Text
+// markers are also shown when the theme cannot tell usage levels apart by
+// color, as with monochrome and NO_COLOR.
+func useGlyphs(accessible bool) {
+	glyphs = &unicodeGlyphs
+	if accessible {
+		glyphs = &asciiGlyphs
+		tview.Borders.Horizontal = '-'
+		tview.Borders.Vertical = '|'
+		tview.Borders.TopLeft = '+'
+		tview.Borders.TopRight = '+'
+		tview.Borders.BottomLeft = '+'
+		tview.Borders.BottomRight = '+'
+		tview.Borders.LeftT = '+'
+		tview.Borders.RightT = '+'
+		tview.Borders.TopT = '+'
+		tview.Borders.BottomT = '+'
+		tview.Borders.Cross = '+'
+		tview.Borders.HorizontalFocus = '='
+		tview.Borders.VerticalFocus = '|'
+		tview.Borders.TopLeftFocus = '+'
+		tview.Borders.TopRightFocus = '+'
+		tview.Borders.BottomLeftFocus = '+'
+		tview.Borders.BottomRightFocus = '+'
+	}
+	textMarkers = accessible || activeTheme.Critical == activeTheme.Good
+}
+
+// levelMarker spells out the usage level of percent when text markers are
+// on: "!" above the warning and "!!" above the critical threshold, padded
+// to a fixed width so columns stay aligned.
+func levelMarker(percent float64) string {
+	return markLevel(percent >= activeConfig.Thresholds.Warning, percent >= activeConfig.Thresholds.Critical)
+}
+
+// markLevel is levelMarker for readings with their own limits.
+func markLevel(warning, critical bool) string {
+	switch {
+	case !textMarkers:
+		return ""
+	case critical:
+		return "!!"
+	case warning:
+		return "! "
+	default:
+		return "  "
+	}
+}
+
+// displayWidth is the number of terminal cells s occupies, counting wide
+// runes such as CJK characters and emoji as two. Color tags are not
+// stripped; use tview.TaggedStringWidth for tagged text.
+func displayWidth(s string) int {
+	return uniseg.StringWidth(s)
+}
+
+// truncateLabel shortens value to at most max cells, marking the cut with
+// "..." and never splitting a character.
+func truncateLabel(value string, max int) string {
+	if displayWidth(value) <= max {
+		return value
+	}
+	keep := max - 3
+	if max <= 3 {
+		keep = max
+	}
+	var b strings.Builder
+	width := 0
+	state := -1
+	rest := value
+	for len(rest) > 0 {
+		var cluster string
+		var w int
+		cluster, rest, w, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		if width+w > keep {
+			break
+		}
+		b.WriteString(cluster)
+		width += w
+	}
+	if max > 3 {
+		b.WriteString("...")
+	}
+	return b.String()
+}
+
+// padLabel truncates value to width cells and pads it with spaces to
+// exactly that width, which fmt's %-*s cannot do for wide runes.
+func padLabel(value string, width int) string {
+	value = truncateLabel(value, width)
+	if pad := width - displayWidth(value); pad > 0 {
+		value += strings.Repeat(" ", pad)
+	}
+	return value
+}
@@ -9,10 +9,17 @@ import (
 )
 
 func (d *Dashboard) updateGPU(snap *snapshot) {
+	computePower := d.formatComputePower(snap)
 	if snap == nil || len(snap.GPUInfos) == 0 {
-		d.gpuView.SetText("[gray]No discrete GPU detected[-]")
+		text := mutedTag() + "No discrete GPU detected[-]"
+		if computePower != "" {
+			text = computePower + "\n" + text
+		}
+		d.gpuView.SetText(text)
+		d.fitGPUPanel(strings.Count(text, "\n") + 1)
 		return
 	}
+	d.fitGPUPanel(0)
 
 	_, _, width, _ := d.gpuView.GetInnerRect()
 	if width <= 0 {
@@ -20,6 +27,9 @@ func (d *Dashboard) updateGPU(snap *snapshot) {
 	}
 
 	var lines []string
+	if computePower != "" {
+		lines = append(lines, computePower, "")
+	}
 
 	for _, gpu := range snap.GPUInfos {
 		// Header line: [index] GPU Name (Driver: version)
@@ -87,7 +97,7 @@ func (d *Dashboard) updateGPU(snap *snapshot) {
 
 		// Temperature bar with Power and Fan
 		tempBar := renderBtopBar(gpu.Temperature, 15)
-		tempLine := fmt.Sprintf("  Temp: %s %.0f°C", tempBar, gpu.Temperature)
+		tempLine := fmt.Sprintf("  Temp: %s %s", tempBar, formatTemperature(gpu.Temperature, 0))
 
 		powerInfo := formatGPUPower(gpu)
 		if powerInfo != "Power N/A" {
@@ -129,10 +139,10 @@ func (d *Dashboard) updateGPU(snap *snapshot) {
 					if procType == "" {
 						procType = "Compute"
 					}
-					name := truncateLabel(proc.ProcessName, 25)
+					name := padLabel(proc.ProcessName, 25)
 					memMB := proc.MemoryUsed
 					totalProcMem += memMB
-					procLine := fmt.Sprintf("    %-6d  %-25s  %-8s  %6.0fMB",
+					procLine := fmt.Sprintf("    %-6d  %s  %-8s  %6.0fMB",
 						proc.PID,
 						name,
 						procType,
@@ -152,16 +162,6 @@ func (d *Dashboard) updateGPU(snap *snapshot) {
 	d.gpuView.SetText(strings.TrimSpace(strings.Join(lines, "\n")))
 }
 
-func truncateLabel(value string, max int) string {
-	if len(value) <= max {
-		return value
-	}
-	if max <= 3 {
-		return value[:max]
-	}
-	return value[:max-3] + "..."
-}
-
 func renderBtopBar(value float64, width int) string {
 	width = clampInt(width, 6, 60)
 
@@ -179,9 +179,9 @@ func renderBtopBar(value float64, width int) string {
 	b.WriteString("[")
 	for i := 0; i < width; i++ {
 		if i < filled {
-			b.WriteRune('█')
+			b.WriteRune(glyphs.barFill)
 		} else {
-			b.WriteRune(' ')
+			b.WriteRune(glyphs.barEmpty)
 		}
 	}
 	b.WriteString("]")
@@ -226,3 +226,60 @@ func formatGPUClocks(g *metrics.GPUInfo) string {
 	}
 	return "Clock N/A"
 }
+
+// gpuSeries is the history of one GPU shown on the GPU tab.
+type gpuSeries struct {
+	util, mem, temp, power *sparkHistory
+}
+
+func newGPUSeries(size int) *gpuSeries {
+	return &gpuSeries{
+		util:  newSparkHistory(size),
+		mem:   newSparkHistory(size),
+		temp:  newSparkHistory(size),
+		power: newSparkHistory(size),
+	}
+}
+
+func (s *gpuSeries) push(gpu *metrics.GPUInfo) {
+	s.util.Push(gpu.Utilization)
+	memPercent := 0.0
+	if gpu.MemoryTotal > 0 {
+		memPercent = gpu.MemoryUsed / gpu.MemoryTotal * 100
+	}
+	s.mem.Push(memPercent)
+	s.temp.Push(gpu.Temperature)
+	s.power.Push(gpu.PowerUsage)
+}
+
+func (d *Dashboard) updateGPUHistory(snap *snapshot) {
+	if snap == nil || len(snap.GPUInfos) == 0 {
+		d.gpuHistView.SetText(mutedTag() + "No discrete GPU detected[-]")
+		return
+	}
+	_, _, width, _ := d.gpuHistView.GetInnerRect()
+	if width <= 0 {
+		width = 80
+	}
+	sparkWidth := clampInt(width-20, 8, 120)
+
+	var lines []string
+	for _, gpu := range snap.GPUInfos {
+		hist := d.gpuHistory[gpu.Index]
+		if hist == nil {
+			continue
+		}
+		memPercent := 0.0
+		if gpu.MemoryTotal > 0 {
+			memPercent = gpu.MemoryUsed / gpu.MemoryTotal * 100
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s[[%d]] %s[-]", accentTag(), gpu.Index, gpu.Name),
+			fmt.Sprintf("  Util  %s%6.1f%%%s[-]  %s", colorTag(usageColor(gpu.Utilization)), gpu.Utilization, levelMarker(gpu.Utilization), renderSparkline(hist.util.Series(), sparkWidth)),
+			fmt.Sprintf("  Mem   %s%6.1f%%%s[-]  %s", colorTag(usageColor(memPercent)), memPercent, levelMarker(memPercent), renderSparkline(hist.mem.Series(), sparkWidth)),
+			fmt.Sprintf("  Temp  %7s  %s", formatTemperature(gpu.Temperature, 0), renderSparkline(hist.temp.Series(), sparkWidth)),
+			fmt.Sprintf("  Power %6.0fW  %s", gpu.PowerUsage, renderSparkline(hist.power.Series(), sparkWidth)),
+			"")
+	}
+	d.gpuHistView.SetText(strings.TrimSpace(strings.Join(lines, "\n")))
+}
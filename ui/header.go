@@ -3,13 +3,11 @@ package ui
 import (
 	"fmt"
 	"strings"
-
-	"github.com/gdamore/tcell/v2"
 )
 
 func (d *Dashboard) updateHeader(snap *snapshot, rates netRates) {
 	if snap == nil {
-		d.header.SetText("[yellow]collecting metrics...[-]")
+		d.header.SetText(warnTag() + "collecting metrics...[-]")
 		return
 	}
 
@@ -23,7 +21,7 @@ func (d *Dashboard) updateHeader(snap *snapshot, rates netRates) {
 	}
 
 	reset := resetTag()
-	accent := colorTag(tcell.ColorLightCyan)
+	accent := accentTag()
 
 	hostname := strings.TrimSpace(snap.Hostname)
 	if hostname == "" {
@@ -41,16 +39,20 @@ func (d *Dashboard) updateHeader(snap *snapshot, rates netRates) {
 		lineOne = joinWithSpacing([]string{lineOne, loadStr})
 	}
 
+	if battery := d.formatBattery(snap); battery != "" {
+		lineOne = joinWithSpacing([]string{lineOne, battery})
+	}
+
 	cpuBarWidth := clampInt(width/3, 12, 40)
 	cpuBar := renderUsageBar(snap.TotalCPU, cpuBarWidth)
 	cpuSpark := ""
 	sparkWidth := clampInt(width/3, 8, 40)
 	if d.cpuHistory != nil && sparkWidth >= 8 {
-		cpuSpark = "  " + renderSparkline(d.cpuHistory.Series(), sparkWidth)
+		cpuSpark = "  " + renderSparkline(d.sparkSeries("cpu", d.cpuHistory), sparkWidth)
 	}
 
 	partsLineTwo := []string{
-		fmt.Sprintf("⚙ CPU %s%s", cpuBar, cpuSpark),
+		fmt.Sprintf("%s %s%s", glyphs.cpuLabel, cpuBar, cpuSpark),
 	}
 
 	if snap.Memory != nil {
@@ -58,11 +60,11 @@ func (d *Dashboard) updateHeader(snap *snapshot, rates netRates) {
 		memBar := renderUsageBar(memPercent, cpuBarWidth)
 		memSpark := ""
 		if d.memHistory != nil && sparkWidth >= 8 {
-			memSpark = "  " + renderSparkline(d.memHistory.Series(), sparkWidth)
+			memSpark = "  " + renderSparkline(d.sparkSeries("mem", d.memHistory), sparkWidth)
 		}
 		partsLineTwo = append(partsLineTwo,
-			fmt.Sprintf("💾 MEM %s%s %s/%s",
-				memBar, memSpark,
+			fmt.Sprintf("%s %s%s %s/%s",
+				glyphs.memLabel, memBar, memSpark,
 				formatBytes(float64(snap.Memory.Used)),
 				formatBytes(float64(snap.Memory.Total))))
 	}
@@ -70,16 +72,16 @@ func (d *Dashboard) updateHeader(snap *snapshot, rates netRates) {
 	if snap.Swap != nil && snap.Swap.Total > 0 {
 		swapBar := renderUsageBar(snap.Swap.UsedPercent, clampInt(cpuBarWidth, 10, 30))
 		partsLineTwo = append(partsLineTwo,
-			fmt.Sprintf("🔄 SWP %s %s/%s",
-				swapBar,
+			fmt.Sprintf("%s %s %s/%s",
+				glyphs.swapLabel, swapBar,
 				formatBytes(float64(snap.Swap.Used)),
 				formatBytes(float64(snap.Swap.Total))))
 	} else if snap.Disk != nil && snap.Disk.Total > 0 {
 		diskPercent := (float64(snap.Disk.Used) / float64(snap.Disk.Total)) * 100
 		diskBar := renderUsageBar(diskPercent, clampInt(cpuBarWidth, 10, 30))
 		partsLineTwo = append(partsLineTwo,
-			fmt.Sprintf("📀 DISK %s %s/%s",
-				diskBar,
+			fmt.Sprintf("%s %s %s/%s",
+				glyphs.diskLabel, diskBar,
 				formatBytes(float64(snap.Disk.Used)),
 				formatBytes(float64(snap.Disk.Total))))
 	}
@@ -93,16 +95,16 @@ func (d *Dashboard) updateHeader(snap *snapshot, rates netRates) {
 		upSpark, downSpark := "", ""
 		netSparkWidth := clampInt(width/4, 8, 32)
 		if d.netUpHistory != nil && netSparkWidth >= 8 {
-			upSpark = "  " + renderSparkline(d.netUpHistory.Series(), netSparkWidth)
+			upSpark = "  " + renderSparkline(d.sparkSeries("net.up", d.netUpHistory), netSparkWidth)
 		}
 		if d.netDnHistory != nil && netSparkWidth >= 8 {
-			downSpark = "  " + renderSparkline(d.netDnHistory.Series(), netSparkWidth)
+			downSpark = "  " + renderSparkline(d.sparkSeries("net.down", d.netDnHistory), netSparkWidth)
 		}
 		netLine = joinWithSpacing([]string{
-			fmt.Sprintf("↑ %s%s", up, upSpark),
-			fmt.Sprintf("↓ %s%s", down, downSpark),
+			fmt.Sprintf("%s %s%s", glyphs.up, up, upSpark),
+			fmt.Sprintf("%s %s%s", glyphs.down, down, downSpark),
 		})
-		netLine = fmt.Sprintf("🌐 %s", netLine)
+		netLine = fmt.Sprintf("%s %s", glyphs.netLabel, netLine)
 	}
 
 	if netLine == "" {
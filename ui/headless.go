@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// snapshotVersion is bumped whenever a field of the JSON document is renamed,
+// removed or changes meaning. Adding fields does not change the version.
+const snapshotVersion = 1
+
+const defaultWarmup = time.Second
+
+type snapshotJSON snapshot
+
+func (s *snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Version       int     `json:"version"`
+		UptimeSeconds float64 `json:"uptimeSeconds"`
+		*snapshotJSON
+	}{
+		Version:       snapshotVersion,
+		UptimeSeconds: s.Uptime.Seconds(),
+		snapshotJSON:  (*snapshotJSON)(s),
+	})
+}
+
+func (s *snapshot) UnmarshalJSON(data []byte) error {
+	doc := struct {
+		Version       int     `json:"version"`
+		UptimeSeconds float64 `json:"uptimeSeconds"`
+		*snapshotJSON
+	}{snapshotJSON: (*snapshotJSON)(s)}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Version > snapshotVersion {
+		return fmt.Errorf("snapshot version %d is newer than supported version %d", doc.Version, snapshotVersion)
+	}
+	s.Uptime = time.Duration(doc.UptimeSeconds * float64(time.Second))
+	return nil
+}
+
+// warmUp primes the CPU counters so that the next collection reports usage
+// over the warm-up interval instead of since the previous (missing) sample.
+func warmUp(interval time.Duration) {
+	cpu.Percent(0, false)
+	cpu.Percent(0, true)
+	time.Sleep(interval)
+}
+
+// RunOnce collects a single snapshot and writes it to w without starting
+// the terminal UI.
+func RunOnce(w io.Writer, format string, warmup time.Duration) error {
+	if format != "json" {
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	if warmup <= 0 {
+		warmup = defaultWarmup
+	}
+	warmUp(warmup)
+
+	snap, err := collectSnapshot(maxProcessEntries)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snap)
+}
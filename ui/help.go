@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// keyContexts orders the groups of the help screen.
+var keyContexts = []string{"Replay", "General", "Tabs", "Processes", "Layout", "Navigation"}
+
+// newHelpPage centers the help view over the dashboard, leaving a margin
+// so the dashboard shows around it.
+func (d *Dashboard) newHelpPage() tview.Primitive {
+	d.helpView = d.newSection(" HELP ")
+	d.helpView.SetScrollable(true)
+	column := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 1, 0, false).
+		AddItem(d.helpView, 0, 1, true).
+		AddItem(nil, 1, 0, false)
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(column, 72, 0, true).
+		AddItem(nil, 0, 1, false)
+}
+
+func (d *Dashboard) toggleHelp() {
+	d.helpShown = !d.helpShown
+	if !d.helpShown {
+		d.pages.HidePage("help")
+		d.app.SetFocus(d.focusView())
+		return
+	}
+	d.helpView.SetText(d.helpText()).ScrollToBeginning()
+	d.pages.ShowPage("help")
+	d.app.SetFocus(d.helpView)
+}
+
+// helpKey handles keys while the help screen is open: the help keys, Esc
+// and q close it and the rest scroll it.
+func (d *Dashboard) helpKey(action *keyAction, event *tcell.EventKey) *tcell.EventKey {
+	switch {
+	case action != nil && (action.name == "help" || action.name == "quit"),
+		event.Key() == tcell.KeyEscape,
+		event.Key() == tcell.KeyRune && event.Rune() == 'q':
+		d.toggleHelp()
+		return nil
+	case action != nil && action.run == nil:
+		return tcell.NewEventKey(action.key, 0, tcell.ModNone)
+	}
+	return event
+}
+
+// helpText lists every binding in the keymap by context. Replay controls
+// are only listed, first, during playback.
+func (d *Dashboard) helpText() string {
+	var lines []string
+	for _, context := range keyContexts {
+		if context == "Replay" && d.replay == nil {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, accentTag()+context+"[-]")
+		for _, a := range keyActions {
+			if a.context != context {
+				continue
+			}
+			label := d.keymap.label(a.name)
+			if label == "" {
+				lines = append(lines, fmt.Sprintf("  %s%-16s[-] %s", mutedTag(), "unbound", a.help))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  [::b]%-16s[::-] %s", tview.Escape(label), a.help))
+		}
+		if context == "General" {
+			lines = append(lines, fmt.Sprintf("  [::b]%-16s[::-] %s", "Ctrl+C", "Quit, whatever the keymap says"))
+		}
+	}
+	lines = append(lines, "", mutedTag()+"Rebind keys under keys: in the config file. Esc closes this screen.[-]")
+	return strings.Join(lines, "\n")
+}
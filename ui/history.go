@@ -0,0 +1,411 @@
+package ui
+
+import (
+	"encoding/gob"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// historyVersion is bumped when the on-disk layout of the history store
+// changes; files with another version are ignored rather than misread.
+const historyVersion = 1
+
+const historySaveInterval = 5 * time.Minute
+
+// historyTiers are the resolutions kept for every series, finest first, in
+// the spirit of an RRD archive: each tier averages samples into fixed steps.
+var historyTiers = []struct {
+	step time.Duration
+	span time.Duration
+}{
+	{2 * time.Second, 10 * time.Minute},
+	{time.Minute, 24 * time.Hour},
+	{10 * time.Minute, 30 * 24 * time.Hour},
+}
+
+// historyRanges are the time ranges the sparklines cycle through. The first
+// entry shows the in-memory sparkHistory of the current session.
+var historyRanges = []struct {
+	label string
+	span  time.Duration
+}{
+	{"live", 0},
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// rrdTier is a ring of Slots averaged per Step. Last is the index of the
+// newest bucket (Unix time divided by Step); Sum and Count accumulate the
+// samples that fall into it.
+type rrdTier struct {
+	Step  time.Duration
+	Slots []float32
+	Last  int64
+	Sum   float64
+	Count int
+}
+
+func newRRDTier(step, span time.Duration) *rrdTier {
+	n := int(span / step)
+	t := &rrdTier{Step: step, Slots: make([]float32, n)}
+	for i := range t.Slots {
+		t.Slots[i] = float32(math.NaN())
+	}
+	return t
+}
+
+func (t *rrdTier) slot(bucket int64) *float32 {
+	n := int64(len(t.Slots))
+	return &t.Slots[((bucket%n)+n)%n]
+}
+
+func (t *rrdTier) add(at time.Time, v float64) {
+	bucket := at.UnixNano() / int64(t.Step)
+	switch {
+	case bucket < t.Last:
+		return
+	case bucket > t.Last:
+		// Buckets skipped while wtop was not running stay empty.
+		gap := bucket - t.Last - 1
+		if gap > int64(len(t.Slots)) {
+			gap = int64(len(t.Slots))
+		}
+		for b := bucket - gap; b < bucket; b++ {
+			*t.slot(b) = float32(math.NaN())
+		}
+		t.Last, t.Sum, t.Count = bucket, 0, 0
+	}
+	t.Sum += v
+	t.Count++
+	*t.slot(bucket) = float32(t.Sum / float64(t.Count))
+}
+
+func (t *rrdTier) get(bucket int64) float64 {
+	if bucket > t.Last || bucket <= t.Last-int64(len(t.Slots)) {
+		return math.NaN()
+	}
+	return float64(*t.slot(bucket))
+}
+
+// merge fills buckets this tier has no data for from other, so two wtop
+// processes sharing a history file do not erase each other's samples.
+func (t *rrdTier) merge(other *rrdTier) {
+	if other == nil || other.Step != t.Step || len(other.Slots) != len(t.Slots) {
+		return
+	}
+	if other.Last > t.Last {
+		mine := *t
+		mine.Slots = append([]float32(nil), t.Slots...)
+		*t = *other
+		t.Slots = append([]float32(nil), other.Slots...)
+		other = &mine
+	}
+	for b := t.Last - int64(len(t.Slots)) + 1; b <= t.Last; b++ {
+		if math.IsNaN(t.get(b)) {
+			if v := other.get(b); !math.IsNaN(v) {
+				*t.slot(b) = float32(v)
+			}
+		}
+	}
+}
+
+type rrdSeries struct {
+	Tiers []*rrdTier
+}
+
+func newRRDSeries() *rrdSeries {
+	s := &rrdSeries{}
+	for _, tier := range historyTiers {
+		s.Tiers = append(s.Tiers, newRRDTier(tier.step, tier.span))
+	}
+	return s
+}
+
+// historyStore keeps long-range history for every sparkline, keyed like the
+// in-memory histories ("cpu", "mem", "psi.io", "sensor.<chip>/<key>", ...).
+type historyStore struct {
+	Version int
+	Series  map[string]*rrdSeries
+
+	path     string
+	lastSave time.Time
+	saving   bool           // a background write is in flight
+	writes   sync.WaitGroup // background writes, waited for before the final save
+}
+
+func newHistoryStore(path string) *historyStore {
+	return &historyStore{Version: historyVersion, Series: make(map[string]*rrdSeries), path: path}
+}
+
+// DefaultHistoryPath returns $XDG_STATE_HOME/wtop/history.gob, falling back
+// to ~/.local/state.
+func DefaultHistoryPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "wtop", "history.gob")
+}
+
+// loadHistory reads the store at path. A missing file, or one written by an
+// incompatible version, yields an empty store.
+func loadHistory(path string) (*historyStore, error) {
+	h := newHistoryStore(path)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var disk historyStore
+	if err := gob.NewDecoder(f).Decode(&disk); err != nil {
+		return nil, fmt.Errorf("history %s: %w", path, err)
+	}
+	if disk.Version == historyVersion && disk.Series != nil {
+		h.Series = disk.Series
+	}
+	return h, nil
+}
+
+func (h *historyStore) add(key string, at time.Time, v float64) {
+	s := h.Series[key]
+	if s == nil {
+		s = newRRDSeries()
+		h.Series[key] = s
+	}
+	for _, tier := range s.Tiers {
+		tier.add(at, v)
+	}
+}
+
+// record adds every sparkline value of snap. prev, if any, is used for the
+// network rates.
+func (h *historyStore) record(prev, snap *snapshot) {
+	at := snap.Timestamp
+	h.add("cpu", at, snap.TotalCPU)
+	if snap.Memory != nil {
+		h.add("mem", at, snap.Memory.UsedPercent)
+	}
+	if snap.Swap != nil && snap.Swap.Total > 0 {
+		h.add("swap", at, snap.Swap.UsedPercent)
+	}
+	if snap.Disk != nil && snap.Disk.Total > 0 {
+		h.add("disk", at, float64(snap.Disk.Used)/float64(snap.Disk.Total)*100)
+	}
+	if prev != nil {
+		if elapsed := at.Sub(prev.Timestamp).Seconds(); elapsed > 0 {
+			h.add("net.up", at, counterRate(prev.NetBytesSent, snap.NetBytesSent, elapsed))
+			h.add("net.down", at, counterRate(prev.NetBytesRecv, snap.NetBytesRecv, elapsed))
+		}
+	}
+	if p := snap.Pressure; p != nil {
+		if p.CPU != nil {
+			h.add("psi.cpu", at, p.CPU.Some.Avg10)
+		}
+		if p.Memory != nil {
+			h.add("psi.mem", at, p.Memory.Some.Avg10)
+		}
+		if p.IO != nil {
+			h.add("psi.io", at, p.IO.Some.Avg10)
+		}
+	}
+	for _, chip := range snap.Sensors {
+		for _, r := range chip.Readings {
+			h.add("sensor."+sensorHistoryKey(chip, r), at, r.Value)
+		}
+	}
+}
+
+// series returns the values of key over the span ending at now, oldest
+// first, from the finest tier that covers the span. Empty buckets are 0,
+// which renderSparkline draws as a gap.
+func (h *historyStore) series(key string, span time.Duration, now time.Time) []float64 {
+	s := h.Series[key]
+	if s == nil {
+		return nil
+	}
+	tier := s.Tiers[len(s.Tiers)-1]
+	for _, t := range s.Tiers {
+		if time.Duration(len(t.Slots))*t.Step >= span {
+			tier = t
+			break
+		}
+	}
+	n := int64(span / tier.Step)
+	end := now.UnixNano() / int64(tier.Step)
+	values := make([]float64, 0, n)
+	for b := end - n + 1; b <= end; b++ {
+		v := tier.get(b)
+		if math.IsNaN(v) {
+			v = 0
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// save merges with whatever is on disk and writes the store atomically.
+func (h *historyStore) save() error {
+	if h.path == "" {
+		return nil
+	}
+	if disk, err := loadHistory(h.path); err == nil {
+		for key, theirs := range disk.Series {
+			mine := h.Series[key]
+			if mine == nil {
+				h.Series[key] = theirs
+				continue
+			}
+			for i, tier := range mine.Tiers {
+				if i < len(theirs.Tiers) {
+					tier.merge(theirs.Tiers[i])
+				}
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), ".history-*")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(tmp).Encode(h); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
+
+// clone returns a copy of the store that a background writer can save while
+// the original keeps recording.
+func (h *historyStore) clone() *historyStore {
+	c := newHistoryStore(h.path)
+	for key, series := range h.Series {
+		cs := &rrdSeries{Tiers: make([]*rrdTier, len(series.Tiers))}
+		for i, tier := range series.Tiers {
+			t := *tier
+			t.Slots = append([]float32(nil), tier.Slots...)
+			cs.Tiers[i] = &t
+		}
+		c.Series[key] = cs
+	}
+	return c
+}
+
+// saveDue reports whether a save is due, at most once per
+// historySaveInterval so a crash loses only the last few minutes.
+func (h *historyStore) saveDue(now time.Time) bool {
+	if h.lastSave.IsZero() {
+		h.lastSave = now
+		return false
+	}
+	if now.Sub(h.lastSave) < historySaveInterval {
+		return false
+	}
+	h.lastSave = now
+	return true
+}
+
+// maybeSave saves synchronously when a save is due; serve mode has no UI to
+// stall.
+func (h *historyStore) maybeSave(now time.Time) error {
+	if !h.saveDue(now) {
+		return nil
+	}
+	return h.save()
+}
+
+// historySink feeds the store from serve mode, so a background `wtop serve`
+// fills in the ranges the dashboard shows later.
+type historySink struct {
+	store *historyStore
+	prev  *snapshot
+}
+
+func (s *historySink) push(snap *snapshot) {
+	if snap == nil {
+		return
+	}
+	s.store.record(s.prev, snap)
+	s.prev = snap
+	if err := s.store.maybeSave(snap.Timestamp); err != nil {
+		log.Printf("wtop: history: %v", err)
+	}
+}
+
+// saveHistory hands a copy of the store to a background writer when a save is
+// due, so a slow disk cannot stall the dashboard. A write still in flight
+// postpones the next one.
+func (d *Dashboard) saveHistory(now time.Time) {
+	h := d.history
+	if h.saving || !h.saveDue(now) {
+		return
+	}
+	h.saving = true
+	h.writes.Add(1)
+	c := h.clone()
+	go func() {
+		err := c.save()
+		h.writes.Done()
+		d.app.QueueUpdateDraw(func() {
+			h.saving = false
+			d.historyErr = err
+		})
+	}()
+}
+
+// EnableHistory loads the long-range history at path and keeps it updated,
+// so the sparklines can show ranges beyond the current session.
+func (d *Dashboard) EnableHistory(path string) error {
+	h, err := loadHistory(path)
+	if err != nil {
+		return err
+	}
+	d.history = h
+	return nil
+}
+
+// sparkSeries returns the values to draw for a sparkline: the session's live
+// history, or the stored history for the selected range.
+func (d *Dashboard) sparkSeries(key string, live *sparkHistory) []float64 {
+	if d.historyRange == 0 || d.history == nil || d.lastSnapshot == nil {
+		return live.Series()
+	}
+	return d.history.series(key, historyRanges[d.historyRange].span, d.lastSnapshot.Timestamp)
+}
+
+func (d *Dashboard) cycleHistoryRange() {
+	if d.history == nil {
+		d.footer.SetText(warnTag() + "Long-range history is off (--history-file is empty)[-]")
+		return
+	}
+	d.historyRange = (d.historyRange + 1) % len(historyRanges)
+	if d.lastSnapshot != nil {
+		d.updateHeader(d.lastSnapshot, d.lastRates)
+		d.updateCPU(d.lastSnapshot)
+		d.updateMemory(d.lastSnapshot)
+		d.updateSensors(d.lastSnapshot)
+		d.updateFooter(d.lastSnapshot, d.lastRates)
+	}
+}
@@ -0,0 +1,280 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// keyAction is something a key can be bound to. Actions either run a
+// function or, for navigation, stand in for another key so that the focused
+// table or panel scrolls as if that key had been pressed.
+type keyAction struct {
+	name    string // key in the config file's keys section
+	context string // heading in the help screen
+	help    string
+	keys    []string // default bindings
+	// when limits the action to a mode, such as replay; otherwise the key
+	// goes on to the focused view.
+	when func(d *Dashboard) bool
+	run  func(d *Dashboard)
+	key  tcell.Key // navigation only
+}
+
+// keyActions is the central keymap: the help screen and the footer hints
+// are generated from it and the config file can rebind any of it. It is
+// filled in by init because the help screen refers to it. Replay controls
+// come first so that during playback they take over the arrow, Home and
+// End keys from navigation.
+var keyActions []*keyAction
+
+func init() {
+	keyActions = []*keyAction{
+		{name: "replay_pause", context: "Replay", help: "Pause or resume playback", keys: []string{"space"}, when: replaying, run: func(d *Dashboard) { d.replayTogglePause() }},
+		{name: "replay_step", context: "Replay", help: "Step one frame forward", keys: []string{"."}, when: replaying, run: func(d *Dashboard) { d.replayStep(1) }},
+		{name: "replay_step_back", context: "Replay", help: "Step one frame back", keys: []string{","}, when: replaying, run: func(d *Dashboard) { d.replayStep(-1) }},
+		{name: "replay_forward", context: "Replay", help: "Seek 30s forward", keys: []string{"right"}, when: replaying, run: func(d *Dashboard) { d.replaySeek(replaySeekStep) }},
+		{name: "replay_back", context: "Replay", help: "Seek 30s back", keys: []string{"left"}, when: replaying, run: func(d *Dashboard) { d.replaySeek(-replaySeekStep) }},
+		{name: "replay_faster", context: "Replay", help: "Play faster", keys: []string{"+", "="}, when: replaying, run: func(d *Dashboard) { d.replaySpeed(1) }},
+		{name: "replay_slower", context: "Replay", help: "Play slower", keys: []string{"-"}, when: replaying, run: func(d *Dashboard) { d.replaySpeed(-1) }},
+		{name: "replay_start", context: "Replay", help: "Jump to the start", keys: []string{"home"}, when: replaying, run: func(d *Dashboard) { d.replayJump(false) }},
+		{name: "replay_end", context: "Replay", help: "Jump to the end", keys: []string{"end"}, when: replaying, run: func(d *Dashboard) { d.replayJump(true) }},
+
+		{name: "help", context: "General", help: "Show or hide this help", keys: []string{"f1", "?"}, run: func(d *Dashboard) { d.toggleHelp() }},
+		{name: "palette", context: "General", help: "Open the command palette", keys: []string{":"}, run: func(d *Dashboard) { d.openPalette() }},
+		{name: "quit", context: "General", help: "Quit", keys: []string{"q", "Q"}, run: func(d *Dashboard) { d.quit() }},
+		{name: "history_range", context: "General", help: "Cycle the graph time range", keys: []string{"r", "R"}, run: func(d *Dashboard) { d.cycleHistoryRange() }},
+		{name: "mem_detail", context: "General", help: "Toggle the memory breakdown", keys: []string{"m", "M"}, run: func(d *Dashboard) { d.toggleMemoryDetail() }},
+		{name: "dump", context: "General", help: "Dump the flight recorder", keys: []string{"d", "D"}, run: func(d *Dashboard) { d.dumpFlightRecorder() }},
+
+		{name: "tab_overview", context: "Tabs", help: "Overview", keys: []string{"1"}, run: func(d *Dashboard) { d.switchTab(tabOverview) }},
+		{name: "tab_processes", context: "Tabs", help: "Processes", keys: []string{"2"}, run: func(d *Dashboard) { d.switchTab(tabProcesses) }},
+		{name: "tab_network", context: "Tabs", help: "Network", keys: []string{"3"}, run: func(d *Dashboard) { d.switchTab(tabNetwork) }},
+		{name: "tab_disks", context: "Tabs", help: "Disks", keys: []string{"4"}, run: func(d *Dashboard) { d.switchTab(tabDisks) }},
+		{name: "tab_gpu", context: "Tabs", help: "GPU", keys: []string{"5"}, run: func(d *Dashboard) { d.switchTab(tabGPU) }},
+		{name: "tab_sensors", context: "Tabs", help: "Sensors", keys: []string{"6"}, run: func(d *Dashboard) { d.switchTab(tabSensors) }},
+
+		{name: "filter", context: "Processes", help: "Filter by PID, user, name or command", keys: []string{"/"}, run: func(d *Dashboard) { d.startFilter() }},
+		{name: "sort", context: "Processes", help: "Cycle the sort order", keys: []string{"s", "S"}, run: func(d *Dashboard) { d.cycleSortMode() }},
+		{name: "pss", context: "Processes", help: "Toggle PSS, USS and swap columns", keys: []string{"p", "P"}, run: func(d *Dashboard) { d.toggleProcessMemoryDetail() }},
+		{name: "terminate", context: "Processes", help: "Send SIGTERM to the selected process", keys: []string{"k"}, run: func(d *Dashboard) { d.requestSignal("TERM") }},
+		{name: "kill", context: "Processes", help: "Send SIGKILL to the selected process", keys: []string{"K"}, run: func(d *Dashboard) { d.requestSignal("KILL") }},
+
+		{name: "next_panel", context: "Layout", help: "Focus the next panel", keys: []string{"tab"}, run: func(d *Dashboard) { d.cycleFocus(1) }},
+		{name: "prev_panel", context: "Layout", help: "Focus the previous panel", keys: []string{"shift+tab"}, run: func(d *Dashboard) { d.cycleFocus(-1) }},
+		{name: "zoom", context: "Layout", help: "Zoom the focused panel", keys: []string{"z", "Z"}, run: func(d *Dashboard) { d.toggleZoom() }},
+		{name: "unzoom", context: "Layout", help: "Leave the zoomed panel", keys: []string{"esc"}, when: zoomed, run: func(d *Dashboard) { d.toggleZoom() }},
+		{name: "hide", context: "Layout", help: "Hide the focused panel", keys: []string{"x", "X"}, run: func(d *Dashboard) { d.hideFocusedPanel() }},
+		{name: "move_up", context: "Layout", help: "Move the focused panel up", keys: []string{"<"}, run: func(d *Dashboard) { d.moveFocusedPanel(-1) }},
+		{name: "move_down", context: "Layout", help: "Move the focused panel down", keys: []string{">"}, run: func(d *Dashboard) { d.moveFocusedPanel(1) }},
+		{name: "shrink", context: "Layout", help: "Shrink the focused panel", keys: []string{"["}, run: func(d *Dashboard) { d.resizeFocusedPanel(-1) }},
+		{name: "grow", context: "Layout", help: "Grow the focused panel", keys: []string{"]"}, run: func(d *Dashboard) { d.resizeFocusedPanel(1) }},
+		{name: "reset_layout", context: "Layout", help: "Restore the layout", keys: []string{"a", "A"}, run: func(d *Dashboard) { d.resetLayout() }},
+		{name: "next_layout", context: "Layout", help: "Switch to the next saved layout", keys: []string{"L"}, run: func(d *Dashboard) { d.cycleLayout() }},
+		{name: "save_layout", context: "Layout", help: "Save the layout to the config file", keys: []string{"W"}, run: func(d *Dashboard) { d.saveLayout() }},
+
+		{name: "up", context: "Navigation", help: "Up", keys: []string{"up"}, key: tcell.KeyUp},
+		{name: "down", context: "Navigation", help: "Down", keys: []string{"down"}, key: tcell.KeyDown},
+		{name: "left", context: "Navigation", help: "Left", keys: []string{"left"}, key: tcell.KeyLeft},
+		{name: "right", context: "Navigation", help: "Right", keys: []string{"right"}, key: tcell.KeyRight},
+		{name: "page_up", context: "Navigation", help: "Page up", keys: []string{"pgup"}, key: tcell.KeyPgUp},
+		{name: "page_down", context: "Navigation", help: "Page down", keys: []string{"pgdn"}, key: tcell.KeyPgDn},
+		{name: "top", context: "Navigation", help: "Top", keys: []string{"home"}, key: tcell.KeyHome},
+		{name: "bottom", context: "Navigation", help: "Bottom", keys: []string{"end"}, key: tcell.KeyEnd},
+	}
+}
+
+func replaying(d *Dashboard) bool { return d.replay != nil }
+func zoomed(d *Dashboard) bool    { return d.zoomed != "" }
+
+// keySpec is one parsed binding: a rune, or a special key when r is 0.
+type keySpec struct {
+	key tcell.Key
+	r   rune
+}
+
+func (k keySpec) matches(event *tcell.EventKey) bool {
+	if k.r != 0 {
+		return event.Key() == tcell.KeyRune && event.Rune() == k.r
+	}
+	return event.Key() == k.key
+}
+
+// String names k the way the config file and the help screen spell it.
+func (k keySpec) String() string {
+	if k.r == ' ' {
+		return "Space"
+	}
+	if k.r != 0 {
+		return string(k.r)
+	}
+	for name, key := range specialKeys {
+		if key == k.key && specialKeyLabels[name] != "" {
+			return specialKeyLabels[name]
+		}
+	}
+	if k.key >= tcell.KeyCtrlA && k.key <= tcell.KeyCtrlZ {
+		return fmt.Sprintf("Ctrl+%c", 'A'+rune(k.key-tcell.KeyCtrlA))
+	}
+	return tcell.KeyNames[k.key]
+}
+
+var specialKeys = map[string]tcell.Key{
+	"esc": tcell.KeyEscape, "escape": tcell.KeyEscape,
+	"enter": tcell.KeyEnter, "return": tcell.KeyEnter,
+	"tab": tcell.KeyTab, "shift+tab": tcell.KeyBacktab, "backtab": tcell.KeyBacktab,
+	"up": tcell.KeyUp, "down": tcell.KeyDown, "left": tcell.KeyLeft, "right": tcell.KeyRight,
+	"home": tcell.KeyHome, "end": tcell.KeyEnd,
+	"pgup": tcell.KeyPgUp, "pageup": tcell.KeyPgUp, "pgdn": tcell.KeyPgDn, "pagedown": tcell.KeyPgDn,
+	"insert": tcell.KeyInsert, "delete": tcell.KeyDelete, "backspace": tcell.KeyBackspace2,
+}
+
+// specialKeyLabels is how the help screen shows the special keys; aliases
+// have none.
+var specialKeyLabels = map[string]string{
+	"esc": "Esc", "enter": "Enter", "tab": "Tab", "shift+tab": "Shift+Tab",
+	"up": "Up", "down": "Down", "left": "Left", "right": "Right",
+	"home": "Home", "end": "End", "pgup": "PgUp", "pgdn": "PgDn",
+	"insert": "Insert", "delete": "Delete", "backspace": "Backspace",
+}
+
+// parseKey reads a binding from the config file: a single character
+// (case matters), "space", a special key name such as "esc" or "pgdn",
+// "f1" to "f12", or "ctrl+" and a letter.
+func parseKey(s string) (keySpec, error) {
+	if utf8.RuneCountInString(s) == 1 {
+		r, _ := utf8.DecodeRuneInString(s)
+		return keySpec{key: tcell.KeyRune, r: r}, nil
+	}
+	name := strings.ToLower(s)
+	if name == "space" {
+		return keySpec{key: tcell.KeyRune, r: ' '}, nil
+	}
+	if key, ok := specialKeys[name]; ok {
+		return keySpec{key: key}, nil
+	}
+	var n int
+	if _, err := fmt.Sscanf(name, "f%d", &n); err == nil && n >= 1 && n <= 12 && name == fmt.Sprintf("f%d", n) {
+		return keySpec{key: tcell.KeyF1 + tcell.Key(n-1)}, nil
+	}
+	if letter := strings.TrimPrefix(name, "ctrl+"); letter != name && len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' {
+		return keySpec{key: tcell.KeyCtrlA + tcell.Key(letter[0]-'a')}, nil
+	}
+	return keySpec{}, fmt.Errorf("unknown key %q", s)
+}
+
+// keymap holds the bindings of every action by name.
+type keymap map[string][]keySpec
+
+// buildKeymap applies the config file's rebinding to the defaults and
+// reports unknown actions, unreadable keys and keys bound twice.
+func buildKeymap(overrides map[string][]string) (keymap, []string) {
+	var problems []string
+	byName := make(map[string]*keyAction, len(keyActions))
+	for _, a := range keyActions {
+		byName[a.name] = a
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if byName[name] == nil {
+			problems = append(problems, fmt.Sprintf("keys.%s: unknown action", name))
+		}
+	}
+
+	km := keymap{}
+	for _, a := range keyActions {
+		keys, ok := overrides[a.name]
+		if !ok {
+			keys = a.keys
+		}
+		for i, s := range keys {
+			k, err := parseKey(s)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("keys.%s[%d]: %v", a.name, i, err))
+				continue
+			}
+			if k.key == tcell.KeyCtrlC {
+				problems = append(problems, fmt.Sprintf("keys.%s[%d]: ctrl+c is reserved for quitting", a.name, i))
+				continue
+			}
+			km[a.name] = append(km[a.name], k)
+		}
+	}
+
+	// Two actions conflict when they share a key and can be active at the
+	// same time. Replay controls override navigation during playback.
+	owner := map[keySpec]*keyAction{}
+	for _, a := range keyActions {
+		for _, k := range km[a.name] {
+			other := owner[k]
+			if other == nil {
+				owner[k] = a
+				continue
+			}
+			if other.context == "Replay" && a.context == "Navigation" {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("keys: %s is bound to both %s and %s", k, other.name, a.name))
+		}
+	}
+	return km, problems
+}
+
+// lookup returns the action event is bound to in the dashboard's current
+// state, or nil.
+func (km keymap) lookup(d *Dashboard, event *tcell.EventKey) *keyAction {
+	for _, a := range keyActions {
+		if a.when != nil && !a.when(d) {
+			continue
+		}
+		for _, k := range km[a.name] {
+			if k.matches(event) {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// label returns the keys of the named action for the help screen and the
+// footer, such as "s/S", or "" if it is unbound.
+func (km keymap) label(name string) string {
+	keys := km[name]
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = k.String()
+	}
+	return strings.Join(labels, "/")
+}
+
+// hint is the first key of the named action, for the footer.
+func (km keymap) hint(name string) string {
+	if keys := km[name]; len(keys) > 0 {
+		return keys[0].String()
+	}
+	return ""
+}
+
+// keyPair is the first keys of two related actions joined by sep, or
+// arrows when both are still on arrow keys.
+func (km keymap) keyPair(first, second, sep, arrows string) string {
+	a, b := km.hint(first), km.hint(second)
+	if a == "" || b == "" {
+		return a + b
+	}
+	if arrows != "" && km[first][0].isArrow() && km[second][0].isArrow() {
+		return arrows
+	}
+	return a + sep + b
+}
+
+func (k keySpec) isArrow() bool {
+	return k.r == 0 && k.key >= tcell.KeyUp && k.key <= tcell.KeyLeft
+}
@@ -0,0 +1,372 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	maxPanelSize     = 10
+	minProcessWidth  = 20
+	maxProcessWidth  = 80
+	processWidthStep = 5
+)
+
+// panelView returns the primitive drawn for the named panel.
+func (d *Dashboard) panelView(name string) tview.Primitive {
+	switch name {
+	case "cpu":
+		return d.cpuView
+	case "memory":
+		return d.memoryView
+	case "disks":
+		return d.diskView
+	case "gpu":
+		return d.gpuView
+	case "sensors":
+		return d.sensorView
+	case "processes":
+		return d.processTable
+	}
+	return nil
+}
+
+func (d *Dashboard) panelSize(name string) int {
+	if size := d.layout.Sizes[name]; size > 0 {
+		return size
+	}
+	return 1
+}
+
+// useLayout switches to the named layout from the config file, "" being
+// the top-level one, and drops any zoom.
+func (d *Dashboard) useLayout(name string) {
+	layout := activeConfig.layout(name)
+	layout.Panels = append([]string(nil), layout.Panels...)
+	sizes := make(map[string]int, len(layout.Sizes))
+	for panel, size := range layout.Sizes {
+		sizes[panel] = size
+	}
+	layout.Sizes = sizes
+	d.layout = layout
+	d.layoutName = name
+	d.zoomed = ""
+	d.buildLayout()
+	if visible := d.visiblePanels(); !containsString(visible, d.focused) {
+		d.focused = visible[0]
+	}
+	d.app.SetFocus(d.focusView())
+}
+
+// visiblePanels is the layout's panels without the GPU panel when GPU
+// collection is off. The process table stands in for an empty layout.
+func (d *Dashboard) visiblePanels() []string {
+	panels := d.layout.Panels
+	if !activeConfig.GPU {
+		panels = removeString(panels, "gpu")
+	}
+	if len(panels) == 0 {
+		return []string{"processes"}
+	}
+	return panels
+}
+
+// buildLayout stacks the enabled panels in the configured order on the left
+// and puts the process table on the right. Memory and disks share a row when
+// they are listed next to each other. A zoomed panel replaces all of them.
+func (d *Dashboard) buildLayout() {
+	panels := d.visiblePanels()
+	isMemDisk := func(name string) bool { return name == "memory" || name == "disks" }
+
+	d.leftFlex = tview.NewFlex().SetDirection(tview.FlexRow)
+	d.memDiskFlex = tview.NewFlex().SetDirection(tview.FlexColumn)
+	d.memoryRow = nil
+	rows := 0
+	for i := 0; i < len(panels); i++ {
+		name := panels[i]
+		if name == "processes" {
+			continue
+		}
+		view := d.panelView(name)
+		rows++
+		if isMemDisk(name) && i+1 < len(panels) && isMemDisk(panels[i+1]) {
+			size := d.panelSize(name)
+			if other := d.panelSize(panels[i+1]); other > size {
+				size = other
+			}
+			d.memDiskFlex.AddItem(view, 0, 1, false).AddItem(d.panelView(panels[i+1]), 0, 1, false)
+			d.leftFlex.AddItem(d.memDiskFlex, 0, size, false)
+			d.memoryRow = d.memDiskFlex
+			i++
+			continue
+		}
+		if name == "gpu" && d.gpuHeight > 0 {
+			d.leftFlex.AddItem(view, d.gpuHeight, 0, false)
+			continue
+		}
+		d.leftFlex.AddItem(view, 0, d.panelSize(name), false)
+		if name == "memory" {
+			d.memoryRow = view
+		}
+	}
+	if d.memDetail && d.memoryRow != nil {
+		d.memDiskFlex.ResizeItem(d.diskView, 0, 0)
+		d.leftFlex.ResizeItem(d.memoryRow, 0, 2*d.panelSize("memory"))
+	}
+
+	d.rightFlex = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(d.processTable, 0, 1, true)
+
+	d.mainFlex = tview.NewFlex().SetDirection(tview.FlexColumn)
+	if rows > 0 {
+		d.mainFlex.AddItem(d.leftFlex, 0, 1, false)
+	}
+	if containsString(panels, "processes") {
+		d.mainFlex.AddItem(d.rightFlex, 0, 1, false)
+	}
+
+	d.body.Clear()
+	if d.zoomed != "" {
+		d.body.AddItem(d.panelView(d.zoomed), 0, 1, false)
+		d.root.ResizeItem(d.tabBar, 0, 0)
+		d.root.ResizeItem(d.header, 0, 0)
+	} else {
+		d.body.AddItem(d.tabView(d.tab), 0, 1, false)
+		d.root.ResizeItem(d.tabBar, 1, 0)
+		d.root.ResizeItem(d.header, 3, 0)
+	}
+	d.lastLayoutWidth = 0
+	d.relayout = true
+}
+
+func (d *Dashboard) reflowLayout(width int) {
+	if width <= 0 {
+		return
+	}
+	if width == d.lastLayoutWidth {
+		return
+	}
+	d.lastLayoutWidth = width
+
+	processWidth := d.layout.ProcessWidth
+	if width < 100 {
+		d.mainFlex.SetDirection(tview.FlexRow)
+		d.mainFlex.ResizeItem(d.leftFlex, 0, 2*(100-processWidth))
+		d.mainFlex.ResizeItem(d.rightFlex, 0, 3*processWidth)
+	} else {
+		d.mainFlex.SetDirection(tview.FlexColumn)
+		d.mainFlex.ResizeItem(d.leftFlex, 0, 100-processWidth)
+		d.mainFlex.ResizeItem(d.rightFlex, 0, processWidth)
+	}
+
+	if width < 80 {
+		d.footer.SetWrap(true)
+	} else {
+		d.footer.SetWrap(false)
+	}
+}
+
+// rerender redraws the panels from the last snapshot once a layout change
+// has been drawn, since their content depends on their size.
+func (d *Dashboard) rerender() {
+	if d.lastSnapshot == nil {
+		return
+	}
+	d.updateProcessTable(d.lastSnapshot)
+	d.updateCPU(d.lastSnapshot)
+	d.updateMemory(d.lastSnapshot)
+	d.updateGPU(d.lastSnapshot)
+	d.updateSensors(d.lastSnapshot)
+	d.updateTab(d.lastSnapshot)
+	d.updateFooter(d.lastSnapshot, d.lastRates)
+}
+
+// fitGPUPanel gives the GPU panel a fixed height of lines when there is no
+// GPU to show, instead of a share of the left column, or its share back
+// with lines 0.
+func (d *Dashboard) fitGPUPanel(lines int) {
+	height := 0
+	if lines > 0 {
+		height = lines + 2
+	}
+	if height == d.gpuHeight {
+		return
+	}
+	d.gpuHeight = height
+	if height > 0 {
+		d.leftFlex.ResizeItem(d.gpuView, height, 0)
+	} else {
+		d.leftFlex.ResizeItem(d.gpuView, 0, d.panelSize("gpu"))
+	}
+	d.relayout = true
+}
+
+func (d *Dashboard) focusPanel(name string) {
+	d.focused = name
+	d.app.SetFocus(d.panelView(name))
+}
+
+// cycleFocus moves the focus to the next or previous visible panel, which
+// the zoom, hide, move and resize keys act on.
+func (d *Dashboard) cycleFocus(step int) {
+	if d.zoomed != "" || d.tab != tabOverview {
+		return
+	}
+	panels := d.visiblePanels()
+	i := indexOf(panels, d.focused)
+	d.focusPanel(panels[(i+step+len(panels))%len(panels)])
+}
+
+func (d *Dashboard) toggleZoom() {
+	switch {
+	case d.zoomed != "":
+		d.zoomed = ""
+	case d.tab != tabOverview:
+		return
+	default:
+		d.zoomed = d.focused
+	}
+	d.buildLayout()
+	d.app.SetFocus(d.panelView(d.focused))
+}
+
+func (d *Dashboard) hideFocusedPanel() {
+	if d.zoomed != "" || d.tab != tabOverview {
+		return
+	}
+	visible := d.visiblePanels()
+	if len(visible) == 1 {
+		d.setNotice("Cannot hide the last panel")
+		return
+	}
+	hidden := d.focused
+	i := indexOf(visible, hidden)
+	d.layout.Panels = removeString(d.layout.Panels, hidden)
+	d.buildLayout()
+	visible = d.visiblePanels()
+	d.focusPanel(visible[i%len(visible)])
+	d.setNotice(fmt.Sprintf("Hid %s, a restores the layout", hidden))
+}
+
+// togglePanel hides the named panel or shows it again at the bottom of
+// the left column.
+func (d *Dashboard) togglePanel(name string) error {
+	if d.panelView(name) == nil {
+		return fmt.Errorf("unknown panel %q", name)
+	}
+	if name == "gpu" && !activeConfig.GPU {
+		return fmt.Errorf("GPU collection is off")
+	}
+	d.zoomed = ""
+	if !containsString(d.layout.Panels, name) {
+		d.layout.Panels = append(d.layout.Panels, name)
+		d.buildLayout()
+		d.setNotice("Showing " + name)
+		return nil
+	}
+	if len(d.visiblePanels()) == 1 {
+		return fmt.Errorf("cannot hide the last panel")
+	}
+	d.layout.Panels = removeString(d.layout.Panels, name)
+	d.buildLayout()
+	if name == d.focused {
+		d.focused = d.visiblePanels()[0]
+	}
+	if d.tab == tabOverview {
+		d.app.SetFocus(d.focusView())
+	}
+	d.setNotice(fmt.Sprintf("Hid %s, a restores the layout", name))
+	return nil
+}
+
+// moveFocusedPanel moves the focused panel up or down the left column.
+// The process table always stays on the right.
+func (d *Dashboard) moveFocusedPanel(step int) {
+	if d.zoomed != "" || d.tab != tabOverview || d.focused == "processes" {
+		return
+	}
+	panels := d.layout.Panels
+	i := indexOf(panels, d.focused)
+	j := i + step
+	for j >= 0 && j < len(panels) && (panels[j] == "processes" || panels[j] == "gpu" && !activeConfig.GPU) {
+		j += step
+	}
+	if j < 0 || j >= len(panels) {
+		return
+	}
+	panels[i], panels[j] = panels[j], panels[i]
+	d.buildLayout()
+	d.app.SetFocus(d.panelView(d.focused))
+}
+
+// resizeFocusedPanel grows or shrinks the focused panel: its height in the
+// left column, or the width of the process table.
+func (d *Dashboard) resizeFocusedPanel(step int) {
+	if d.zoomed != "" || d.tab != tabOverview {
+		return
+	}
+	if d.focused == "processes" {
+		d.layout.ProcessWidth = clampInt(d.layout.ProcessWidth+step*processWidthStep, minProcessWidth, maxProcessWidth)
+		d.lastLayoutWidth = 0
+		d.relayout = true
+		d.setNotice(fmt.Sprintf("Processes %d%% wide", d.layout.ProcessWidth))
+		return
+	}
+	d.layout.Sizes[d.focused] = clampInt(d.panelSize(d.focused)+step, 1, maxPanelSize)
+	d.buildLayout()
+	d.app.SetFocus(d.panelView(d.focused))
+	d.setNotice(fmt.Sprintf("%s size %d", d.focused, d.layout.Sizes[d.focused]))
+}
+
+// cycleLayout switches to the next layout defined in the config file.
+func (d *Dashboard) cycleLayout() {
+	names := append([]string{""}, activeConfig.LayoutNames()...)
+	if len(names) == 1 {
+		d.setNotice("No layouts defined in the config file")
+		return
+	}
+	name := names[(indexOf(names, d.layoutName)+1)%len(names)]
+	d.useLayout(name)
+	d.setNotice("Layout " + layoutLabel(name))
+}
+
+// resetLayout drops the changes made with the layout keys.
+func (d *Dashboard) resetLayout() {
+	d.useLayout(d.layoutName)
+	d.setNotice("Layout " + layoutLabel(d.layoutName) + " restored")
+}
+
+// saveLayout writes the current layout to the config file, replacing the
+// one it started from.
+func (d *Dashboard) saveLayout() {
+	layout := d.layout
+	sizes := map[string]int{}
+	for panel, size := range layout.Sizes {
+		if size > 1 && containsString(layout.Panels, panel) {
+			sizes[panel] = size
+		}
+	}
+	layout.Sizes = sizes
+	if err := activeConfig.saveLayout(d.layoutName, layout); err != nil {
+		d.setNotice(fmt.Sprintf("%s%v[-]", critTag(), err))
+		return
+	}
+	d.setNotice(fmt.Sprintf("Saved layout %s to %s", layoutLabel(d.layoutName), activeConfig.path))
+}
+
+func layoutLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -3,11 +3,15 @@ package ui
 import (
 	"fmt"
 	"strings"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/SwarnenduG07/wtop/metrics"
 )
 
 func (d *Dashboard) updateMemory(snap *snapshot) {
 	if snap == nil || snap.Memory == nil {
-		d.memoryView.SetText("[yellow]Memory metrics unavailable[-]")
+		d.memoryView.SetText(warnTag() + "Memory metrics unavailable[-]")
 		return
 	}
 
@@ -21,7 +25,7 @@ func (d *Dashboard) updateMemory(snap *snapshot) {
 	mem := snap.Memory
 	memSpark := ""
 	if d.memHistory != nil && sparkWidth >= 8 {
-		memSpark = "  " + renderSparkline(d.memHistory.Series(), sparkWidth)
+		memSpark = "  " + renderSparkline(d.sparkSeries("mem", d.memHistory), sparkWidth)
 	}
 	// Split output into memory (left) and disk (right) panes.
 	memLines := []string{}
@@ -59,6 +63,21 @@ func (d *Dashboard) updateMemory(snap *snapshot) {
 			bufPercent))
 	}
 
+	// Pressure stall information
+	if snap.Pressure != nil {
+		if line := renderPressureLine("mem", snap.Pressure.Memory, d.sparkSeries("psi.mem", d.psiMemHistory), sparkWidth); line != "" {
+			memLines = append(memLines, line)
+		}
+	}
+	if line := d.renderCgroupPressure(); line != "" {
+		memLines = append(memLines, line)
+	}
+
+	if d.memDetail {
+		memLines = append(memLines, "")
+		memLines = append(memLines, renderMemoryDetail(mem, snap.KernelMemory)...)
+	}
+
 	// Tasks (keep with memory pane)
 	if snap.ProcessSummary.Total > 0 {
 		memLines = append(memLines, fmt.Sprintf("Tasks %d  Threads %d  Running %d",
@@ -71,7 +90,7 @@ func (d *Dashboard) updateMemory(snap *snapshot) {
 	if snap.Swap != nil && snap.Swap.Total > 0 {
 		swapSpark := ""
 		if d.swapHistory != nil && sparkWidth >= 8 {
-			swapSpark = "  " + renderSparkline(d.swapHistory.Series(), sparkWidth)
+			swapSpark = "  " + renderSparkline(d.sparkSeries("swap", d.swapHistory), sparkWidth)
 		}
 		diskLines = append(diskLines, fmt.Sprintf("Swap %s  %s/%s",
 			renderUsageBar(snap.Swap.UsedPercent, barWidth)+swapSpark,
@@ -83,7 +102,7 @@ func (d *Dashboard) updateMemory(snap *snapshot) {
 		diskPercent := (float64(snap.Disk.Used) / float64(snap.Disk.Total)) * 100
 		diskSpark := ""
 		if d.diskHistory != nil && sparkWidth >= 8 {
-			diskSpark = "  " + renderSparkline(d.diskHistory.Series(), sparkWidth)
+			diskSpark = "  " + renderSparkline(d.sparkSeries("disk", d.diskHistory), sparkWidth)
 		}
 		diskLines = append(diskLines, fmt.Sprintf("Disk %s  %s/%s (%s)",
 			renderUsageBar(diskPercent, barWidth)+diskSpark,
@@ -92,9 +111,76 @@ func (d *Dashboard) updateMemory(snap *snapshot) {
 			snap.DiskPath))
 	}
 
+	if snap.Pressure != nil {
+		if line := renderPressureLine("io", snap.Pressure.IO, d.sparkSeries("psi.io", d.psiIOHistory), sparkWidth); line != "" {
+			diskLines = append(diskLines, line)
+		}
+	}
+
 	// Write into respective views
 	d.memoryView.SetText(strings.Join(memLines, "\n"))
 	if d.diskView != nil {
 		d.diskView.SetText(strings.Join(diskLines, "\n"))
 	}
 }
+
+func renderMemoryDetail(vm *mem.VirtualMemoryStat, km *metrics.KernelMemory) []string {
+	row := func(label string, value uint64) string {
+		percent := 0.0
+		if vm.Total > 0 {
+			percent = float64(value) / float64(vm.Total) * 100
+		}
+		return fmt.Sprintf("%-16s %8s  (%.1f%%)", label, formatBytes(float64(value)), percent)
+	}
+
+	lines := []string{
+		fmt.Sprintf("%sKernel breakdown%s", accentTag(), resetTag()),
+		row("Shared/Shmem:", vm.Shared),
+		row("Slab reclaim:", vm.Sreclaimable),
+		row("Slab unreclaim:", vm.Sunreclaim),
+		row("Dirty:", vm.Dirty),
+		row("Writeback:", vm.WriteBack),
+		row("Page tables:", vm.PageTables),
+		row("Mapped:", vm.Mapped),
+	}
+	if km != nil {
+		lines = append(lines, row("Kernel stack:", km.KernelStack))
+	}
+
+	if vm.HugePagesTotal > 0 {
+		used := (vm.HugePagesTotal - vm.HugePagesFree) * vm.HugePageSize
+		lines = append(lines, fmt.Sprintf("%-16s %d/%d pages of %s (%s used, %d rsvd)",
+			"Huge pages:", vm.HugePagesTotal-vm.HugePagesFree, vm.HugePagesTotal,
+			formatBytes(float64(vm.HugePageSize)), formatBytes(float64(used)), vm.HugePagesRsvd))
+	}
+	thp := vm.AnonHugePages
+	if km != nil {
+		thp += km.ShmemHugePages + km.FileHugePages
+	}
+	if thp > 0 {
+		lines = append(lines, row("THP:", thp))
+	}
+
+	if km != nil && km.Zswapped > 0 {
+		ratio := 0.0
+		if km.Zswap > 0 {
+			ratio = float64(km.Zswapped) / float64(km.Zswap)
+		}
+		lines = append(lines, fmt.Sprintf("%-16s %s -> %s  (%.2fx)", "Zswap:",
+			formatBytes(float64(km.Zswapped)), formatBytes(float64(km.Zswap)), ratio))
+	}
+	if km != nil {
+		for _, z := range km.Zram {
+			lines = append(lines, fmt.Sprintf("%-16s %s -> %s  (%.2fx)", z.Name+":",
+				formatBytes(float64(z.OrigData)), formatBytes(float64(z.MemUsed)), z.Ratio()))
+		}
+	}
+
+	if vm.CommitLimit > 0 {
+		commitPercent := float64(vm.CommittedAS) / float64(vm.CommitLimit) * 100
+		lines = append(lines, fmt.Sprintf("%-16s %s/%s %s(%.0f%%)%s%s", "Committed:",
+			formatBytes(float64(vm.CommittedAS)), formatBytes(float64(vm.CommitLimit)),
+			colorTag(usageColor(commitPercent)), commitPercent, strings.TrimSpace(levelMarker(commitPercent)), resetTag()))
+	}
+	return lines
+}
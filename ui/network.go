@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+	gnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ioHistory keeps the two directions of a device's throughput: received
+// and sent for an interface, read and written for a disk.
+type ioHistory struct {
+	in, out *sparkHistory
+}
+
+func (d *Dashboard) ioHistoryFor(histories map[string]*ioHistory, name string) *ioHistory {
+	hist := histories[name]
+	if hist == nil {
+		hist = &ioHistory{in: newSparkHistory(d.historySize), out: newSparkHistory(d.historySize)}
+		histories[name] = hist
+	}
+	return hist
+}
+
+func pruneIOHistory(histories map[string]*ioHistory, present map[string]bool) {
+	for name := range histories {
+		if !present[name] {
+			delete(histories, name)
+		}
+	}
+}
+
+// computeDeviceRates works out per-interface and per-disk rates against the
+// previous snapshot, the same way computeNetworkRates does for the totals.
+func (d *Dashboard) computeDeviceRates(snap *snapshot, fromLoop bool) {
+	prev := d.prevDevices
+	d.prevDevices = snap
+	d.ifRates = map[string]netRates{}
+	d.diskRates = map[string]diskRates{}
+	if prev == nil || !fromLoop {
+		return
+	}
+	elapsed := snap.Timestamp.Sub(prev.Timestamp).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	prevIfs := make(map[string]gnet.IOCountersStat, len(prev.Interfaces))
+	for _, c := range prev.Interfaces {
+		prevIfs[c.Name] = c
+	}
+	for _, c := range snap.Interfaces {
+		if p, ok := prevIfs[c.Name]; ok {
+			d.ifRates[c.Name] = netRates{
+				Up:    counterRate(p.BytesSent, c.BytesSent, elapsed),
+				Down:  counterRate(p.BytesRecv, c.BytesRecv, elapsed),
+				Valid: true,
+			}
+		}
+	}
+
+	prevDisks := make(map[string]int, len(prev.DiskIO))
+	for i, c := range prev.DiskIO {
+		prevDisks[c.Name] = i
+	}
+	for _, c := range snap.DiskIO {
+		i, ok := prevDisks[c.Name]
+		if !ok {
+			continue
+		}
+		p := prev.DiskIO[i]
+		busy := counterRate(p.IoTime, c.IoTime, elapsed) / 10 // ms per second to percent
+		if busy > 100 {
+			busy = 100
+		}
+		d.diskRates[c.Name] = diskRates{
+			Read:     counterRate(p.ReadBytes, c.ReadBytes, elapsed),
+			Write:    counterRate(p.WriteBytes, c.WriteBytes, elapsed),
+			ReadOps:  counterRate(p.ReadCount, c.ReadCount, elapsed),
+			WriteOps: counterRate(p.WriteCount, c.WriteCount, elapsed),
+			Busy:     busy,
+		}
+	}
+}
+
+func (d *Dashboard) updateNetwork(snap *snapshot) {
+	_, _, width, _ := d.networkView.GetInnerRect()
+	if width <= 0 {
+		width = 80
+	}
+	sparkWidth := clampInt(width-30, 8, 80)
+
+	var lines []string
+	if snap == nil || len(snap.Interfaces) == 0 {
+		lines = append(lines, mutedTag()+"No per-interface counters[-]")
+	} else {
+		for _, c := range snap.Interfaces {
+			lines = append(lines, fmt.Sprintf("%s%s%s  %stotal %s %s  %s %s  errors %d/%d  drops %d/%d[-]",
+				accentTag(), c.Name, resetTag(), mutedTag(),
+				formatBytes(float64(c.BytesRecv)), glyphs.down,
+				formatBytes(float64(c.BytesSent)), glyphs.up,
+				c.Errin, c.Errout, c.Dropin, c.Dropout))
+			rates, hist := d.ifRates[c.Name], d.ifHistory[c.Name]
+			lines = append(lines,
+				fmt.Sprintf("  %-2s %10s  %s", glyphs.down, formatRate(rates.Down, rates.Valid), renderSparkline(hist.series(true), sparkWidth)),
+				fmt.Sprintf("  %-2s %10s  %s", glyphs.up, formatRate(rates.Up, rates.Valid), renderSparkline(hist.series(false), sparkWidth)))
+		}
+	}
+	d.networkView.SetText(strings.Join(lines, "\n"))
+	d.updateConnections(snap)
+}
+
+// series returns the in or out history, or nil for a device not seen yet.
+func (h *ioHistory) series(in bool) []float64 {
+	if h == nil {
+		return nil
+	}
+	if in {
+		return h.in.Series()
+	}
+	return h.out.Series()
+}
+
+func formatRate(value float64, valid bool) string {
+	if !valid {
+		return "-"
+	}
+	return formatBytesPerSec(value)
+}
+
+// connectionList is the last socket list read for the connections table.
+// Reading it walks every process's file descriptors, so it happens on a
+// background goroutine at most once per refresh interval.
+type connectionList struct {
+	conns   []gnet.ConnectionStat
+	names   map[int32]string
+	err     error
+	readAt  time.Time
+	reading bool
+}
+
+// updateConnections lists the machine's TCP and UDP sockets. They are read
+// on demand, only while the network tab is shown, and only for the local
+// machine.
+func (d *Dashboard) updateConnections(snap *snapshot) {
+	if d.localSource() {
+		d.readConnections(snap)
+	}
+	d.renderConnections()
+}
+
+// readConnections starts a background read of the socket list unless one is
+// running or the last one is less than a refresh interval old.
+func (d *Dashboard) readConnections(snap *snapshot) {
+	if d.conns.reading || time.Since(d.conns.readAt) < d.refreshInterval {
+		return
+	}
+	d.conns.reading = true
+	names := map[int32]string{}
+	if snap != nil {
+		for _, p := range snap.Processes {
+			names[p.PID] = p.Name
+		}
+	}
+	go func() {
+		conns, err := gnet.Connections("inet")
+		// Listening sockets and those in use first, then by process.
+		sort.SliceStable(conns, func(i, j int) bool {
+			ri, rj := connectionRank(conns[i]), connectionRank(conns[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return conns[i].Pid < conns[j].Pid
+		})
+		for _, c := range conns {
+			if _, ok := names[c.Pid]; !ok && c.Pid > 0 {
+				name := ""
+				if p, err := process.NewProcess(c.Pid); err == nil {
+					name, _ = p.Name()
+				}
+				names[c.Pid] = name
+			}
+		}
+		d.app.QueueUpdateDraw(func() {
+			d.conns = connectionList{conns: conns, names: names, err: err, readAt: time.Now()}
+			d.renderConnections()
+		})
+	}()
+}
+
+func (d *Dashboard) renderConnections() {
+	table := d.connTable
+	table.Clear()
+	headers := []string{"PROTO", "LOCAL", "REMOTE", "STATE", "PID", "PROCESS"}
+	for col, h := range headers {
+		table.SetCell(0, col, tview.NewTableCell(h).
+			SetTextColor(activeTheme.Accent).
+			SetSelectable(false).
+			SetExpansion(1))
+	}
+	if !d.localSource() {
+		table.SetCell(1, 0, tview.NewTableCell("Connections are only listed for the local machine").
+			SetTextColor(activeTheme.Muted).SetSelectable(false))
+		table.SetTitle(" Connections ")
+		return
+	}
+	if d.conns.err != nil {
+		table.SetCell(1, 0, tview.NewTableCell(d.conns.err.Error()).
+			SetTextColor(activeTheme.Critical).SetSelectable(false))
+		return
+	}
+	if d.conns.readAt.IsZero() {
+		table.SetCell(1, 0, tview.NewTableCell("Reading connections...").
+			SetTextColor(activeTheme.Muted).SetSelectable(false))
+		return
+	}
+
+	for i, c := range d.conns.conns {
+		pid := ""
+		if c.Pid > 0 {
+			pid = fmt.Sprintf("%d", c.Pid)
+		}
+		cells := []string{connectionProto(c), formatAddr(c.Laddr), formatAddr(c.Raddr), c.Status, pid, d.conns.names[c.Pid]}
+		for col, text := range cells {
+			table.SetCell(i+1, col, tview.NewTableCell(tview.Escape(text)).
+				SetTextColor(activeTheme.Text).
+				SetMaxWidth(48).
+				SetExpansion(1))
+		}
+	}
+	table.SetTitle(fmt.Sprintf(" Connections%s%d ", glyphs.sep, len(d.conns.conns)))
+}
+
+func connectionRank(c gnet.ConnectionStat) int {
+	switch c.Status {
+	case "LISTEN":
+		return 0
+	case "ESTABLISHED":
+		return 1
+	case "NONE", "":
+		return 2
+	default:
+		return 3
+	}
+}
+
+func connectionProto(c gnet.ConnectionStat) string {
+	proto := "tcp"
+	if c.Type == 2 { // SOCK_DGRAM
+		proto = "udp"
+	}
+	if strings.Contains(c.Laddr.IP, ":") {
+		proto += "6"
+	}
+	return proto
+}
+
+func formatAddr(a gnet.Addr) string {
+	if a.Port == 0 {
+		return "*"
+	}
+	if strings.Contains(a.IP, ":") {
+		return fmt.Sprintf("[%s]:%d", a.IP, a.Port)
+	}
+	return fmt.Sprintf("%s:%d", a.IP, a.Port)
+}
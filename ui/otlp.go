@@ -0,0 +1,384 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	otlpProtocolHTTP = "http/protobuf"
+	otlpProtocolGRPC = "grpc"
+
+	otlpGRPCPath = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+)
+
+type OTLPOptions struct {
+	Endpoint string
+	Protocol string
+	Headers  map[string]string
+	Timeout  time.Duration
+}
+
+// otlpExporter pushes snapshots to an OpenTelemetry collector. Requests are
+// hand-encoded protobuf so that wtop does not pull in the OTel SDK.
+type otlpExporter struct {
+	opts   OTLPOptions
+	url    string
+	client *http.Client
+	start  time.Time
+}
+
+func newOTLPExporter(opts OTLPOptions) (*otlpExporter, error) {
+	if opts.Protocol == "" {
+		opts.Protocol = otlpProtocolHTTP
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	exp := &otlpExporter{opts: opts, start: time.Now()}
+	switch opts.Protocol {
+	case otlpProtocolHTTP:
+		endpoint := opts.Endpoint
+		if endpoint == "" {
+			endpoint = "http://localhost:4318"
+		}
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+		if !strings.HasSuffix(endpoint, "/v1/metrics") {
+			endpoint = strings.TrimSuffix(endpoint, "/") + "/v1/metrics"
+		}
+		exp.url = endpoint
+		exp.client = &http.Client{Timeout: opts.Timeout}
+	case otlpProtocolGRPC:
+		endpoint := opts.Endpoint
+		if endpoint == "" {
+			endpoint = "localhost:4317"
+		}
+		secure := strings.HasPrefix(endpoint, "https://")
+		endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")
+		transport := &http.Transport{Protocols: new(http.Protocols)}
+		if secure {
+			transport.Protocols.SetHTTP2(true)
+			exp.url = "https://" + endpoint + otlpGRPCPath
+		} else {
+			transport.Protocols.SetUnencryptedHTTP2(true)
+			exp.url = "http://" + endpoint + otlpGRPCPath
+		}
+		exp.client = &http.Client{Timeout: opts.Timeout, Transport: transport}
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q (want %s or %s)", opts.Protocol, otlpProtocolHTTP, otlpProtocolGRPC)
+	}
+	return exp, nil
+}
+
+func (e *otlpExporter) export(snap *snapshot) error {
+	payload := encodeOTLPMetrics(snap, e.start)
+
+	var body []byte
+	contentType := "application/x-protobuf"
+	if e.opts.Protocol == otlpProtocolGRPC {
+		// gRPC length-prefixed message: compression flag + big-endian size.
+		body = make([]byte, 5+len(payload))
+		binary.BigEndian.PutUint32(body[1:5], uint32(len(payload)))
+		copy(body[5:], payload)
+		contentType = "application/grpc"
+	} else {
+		body = payload
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if e.opts.Protocol == otlpProtocolGRPC {
+		req.Header.Set("TE", "trailers")
+	}
+	for k, v := range e.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp export: %s", resp.Status)
+	}
+	if e.opts.Protocol == otlpProtocolGRPC {
+		status := resp.Trailer.Get("Grpc-Status")
+		if status == "" {
+			status = resp.Header.Get("Grpc-Status")
+		}
+		if status != "" && status != "0" {
+			return fmt.Errorf("otlp export: grpc status %s: %s", status, resp.Trailer.Get("Grpc-Message"))
+		}
+	}
+	return nil
+}
+
+// pbBuf is a minimal protobuf wire-format writer covering the field types
+// used by the OTLP metrics messages.
+type pbBuf struct {
+	b []byte
+}
+
+func (p *pbBuf) varint(v uint64) {
+	p.b = binary.AppendUvarint(p.b, v)
+}
+
+func (p *pbBuf) tag(field int, wire int) {
+	p.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (p *pbBuf) bytesField(field int, data []byte) {
+	p.tag(field, 2)
+	p.varint(uint64(len(data)))
+	p.b = append(p.b, data...)
+}
+
+func (p *pbBuf) stringField(field int, s string) {
+	if s == "" {
+		return
+	}
+	p.bytesField(field, []byte(s))
+}
+
+func (p *pbBuf) message(field int, build func(*pbBuf)) {
+	var inner pbBuf
+	build(&inner)
+	p.bytesField(field, inner.b)
+}
+
+func (p *pbBuf) fixed64(field int, v uint64) {
+	p.tag(field, 1)
+	p.b = binary.LittleEndian.AppendUint64(p.b, v)
+}
+
+func (p *pbBuf) double(field int, v float64) {
+	p.fixed64(field, math.Float64bits(v))
+}
+
+func (p *pbBuf) boolField(field int, v bool) {
+	if !v {
+		return
+	}
+	p.tag(field, 0)
+	p.varint(1)
+}
+
+func (p *pbBuf) enum(field int, v int) {
+	p.tag(field, 0)
+	p.varint(uint64(v))
+}
+
+type otlpAttr struct {
+	key   string
+	value string
+}
+
+type otlpPoint struct {
+	attrs []otlpAttr
+	value float64
+}
+
+type otlpMetric struct {
+	name        string
+	description string
+	unit        string
+	// sum metrics are cumulative; monotonic ones are counters.
+	sum       bool
+	monotonic bool
+	points    []otlpPoint
+}
+
+func encodeKeyValue(p *pbBuf, field int, attr otlpAttr) {
+	p.message(field, func(kv *pbBuf) {
+		kv.stringField(1, attr.key)
+		kv.message(2, func(v *pbBuf) {
+			v.stringField(1, attr.value)
+		})
+	})
+}
+
+// encodeOTLPMetrics builds an ExportMetricsServiceRequest for one snapshot.
+func encodeOTLPMetrics(snap *snapshot, processStart time.Time) []byte {
+	now := uint64(snap.Timestamp.UnixNano())
+	bootTime := uint64(snap.Timestamp.Add(-snap.Uptime).UnixNano())
+	startTime := uint64(processStart.UnixNano())
+
+	hostname := snap.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	resource := []otlpAttr{
+		{"service.name", "wtop"},
+		{"host.name", hostname},
+		{"os.type", runtime.GOOS},
+	}
+
+	var req pbBuf
+	req.message(1, func(rm *pbBuf) {
+		rm.message(1, func(res *pbBuf) {
+			for _, attr := range resource {
+				encodeKeyValue(res, 1, attr)
+			}
+		})
+		rm.message(2, func(sm *pbBuf) {
+			sm.message(1, func(scope *pbBuf) {
+				scope.stringField(1, "github.com/SwarnenduG07/wtop")
+			})
+			for _, m := range otlpMetrics(snap) {
+				sm.message(2, func(mb *pbBuf) {
+					mb.stringField(1, m.name)
+					mb.stringField(2, m.description)
+					mb.stringField(3, m.unit)
+					points := func(data *pbBuf) {
+						for _, pt := range m.points {
+							data.message(1, func(dp *pbBuf) {
+								if m.sum {
+									if m.monotonic {
+										dp.fixed64(2, bootTime)
+									} else {
+										dp.fixed64(2, startTime)
+									}
+								}
+								dp.fixed64(3, now)
+								dp.double(4, pt.value)
+								for _, attr := range pt.attrs {
+									encodeKeyValue(dp, 7, attr)
+								}
+							})
+						}
+					}
+					if m.sum {
+						mb.message(7, func(sum *pbBuf) {
+							points(sum)
+							sum.enum(2, 2) // AGGREGATION_TEMPORALITY_CUMULATIVE
+							sum.boolField(3, m.monotonic)
+						})
+					} else {
+						mb.message(5, points)
+					}
+				})
+			}
+		})
+	})
+	return req.b
+}
+
+// otlpMetrics maps a snapshot onto OpenTelemetry system semantic-convention
+// metric names. Utilizations are ratios in [0, 1] as the conventions require.
+func otlpMetrics(snap *snapshot) []otlpMetric {
+	var metrics []otlpMetric
+
+	cpuUtil := otlpMetric{name: "system.cpu.utilization", description: "CPU utilization per logical core.", unit: "1"}
+	for i, v := range snap.CPUPerCore {
+		cpuUtil.points = append(cpuUtil.points, otlpPoint{
+			attrs: []otlpAttr{{"system.cpu.logical_number", strconv.Itoa(i)}},
+			value: v / 100,
+		})
+	}
+	metrics = append(metrics, cpuUtil)
+
+	if snap.LoadReported {
+		metrics = append(metrics,
+			otlpMetric{name: "system.cpu.load_average.1m", unit: "{thread}", points: []otlpPoint{{value: snap.Load1}}},
+			otlpMetric{name: "system.cpu.load_average.5m", unit: "{thread}", points: []otlpPoint{{value: snap.Load5}}},
+			otlpMetric{name: "system.cpu.load_average.15m", unit: "{thread}", points: []otlpPoint{{value: snap.Load15}}},
+		)
+	}
+
+	if m := snap.Memory; m != nil {
+		state := func(s string, v uint64) otlpPoint {
+			return otlpPoint{attrs: []otlpAttr{{"system.memory.state", s}}, value: float64(v)}
+		}
+		metrics = append(metrics,
+			otlpMetric{name: "system.memory.usage", description: "Bytes of memory in use.", unit: "By", sum: true,
+				points: []otlpPoint{state("used", m.Used), state("free", m.Free), state("cached", m.Cached), state("buffers", m.Buffers)}},
+			otlpMetric{name: "system.memory.limit", description: "Total memory available to the system.", unit: "By", sum: true,
+				points: []otlpPoint{{value: float64(m.Total)}}},
+			otlpMetric{name: "system.memory.utilization", unit: "1",
+				points: []otlpPoint{{attrs: []otlpAttr{{"system.memory.state", "used"}}, value: m.UsedPercent / 100}}},
+		)
+	}
+
+	if s := snap.Swap; s != nil && s.Total > 0 {
+		metrics = append(metrics, otlpMetric{name: "system.paging.usage", unit: "By", sum: true, points: []otlpPoint{
+			{attrs: []otlpAttr{{"system.paging.state", "used"}}, value: float64(s.Used)},
+			{attrs: []otlpAttr{{"system.paging.state", "free"}}, value: float64(s.Free)},
+		}})
+	}
+
+	if len(snap.Disks) > 0 {
+		usage := otlpMetric{name: "system.filesystem.usage", unit: "By", sum: true}
+		util := otlpMetric{name: "system.filesystem.utilization", unit: "1"}
+		for _, d := range snap.Disks {
+			attrs := func(state string) []otlpAttr {
+				return []otlpAttr{
+					{"system.filesystem.mountpoint", d.Path},
+					{"system.filesystem.type", d.Fstype},
+					{"system.filesystem.state", state},
+				}
+			}
+			usage.points = append(usage.points,
+				otlpPoint{attrs: attrs("used"), value: float64(d.Used)},
+				otlpPoint{attrs: attrs("free"), value: float64(d.Free)})
+			util.points = append(util.points, otlpPoint{attrs: attrs("used")[:2], value: d.UsedPercent / 100})
+		}
+		metrics = append(metrics, usage, util)
+	}
+
+	metrics = append(metrics, otlpMetric{name: "system.network.io", unit: "By", sum: true, monotonic: true, points: []otlpPoint{
+		{attrs: []otlpAttr{{"network.io.direction", "transmit"}}, value: float64(snap.NetBytesSent)},
+		{attrs: []otlpAttr{{"network.io.direction", "receive"}}, value: float64(snap.NetBytesRecv)},
+	}})
+
+	// Every point carries a status so that summing them gives the total.
+	status := func(s string, n int) otlpPoint {
+		return otlpPoint{attrs: []otlpAttr{{"process.status", s}}, value: float64(n)}
+	}
+	ps := snap.ProcessSummary
+	metrics = append(metrics, otlpMetric{name: "system.process.count", unit: "{process}", sum: true, points: []otlpPoint{
+		status("running", ps.Running),
+		status("sleeping", ps.Sleeping),
+		status("stopped", ps.Stopped),
+		status("defunct", ps.Zombie),
+	}})
+
+	if len(snap.GPUInfos) > 0 {
+		util := otlpMetric{name: "hw.gpu.utilization", unit: "1"}
+		memUsage := otlpMetric{name: "hw.gpu.memory.usage", unit: "By", sum: true}
+		memLimit := otlpMetric{name: "hw.gpu.memory.limit", unit: "By", sum: true}
+		temp := otlpMetric{name: "hw.temperature", unit: "Cel"}
+		power := otlpMetric{name: "hw.power", unit: "W"}
+		for _, gpu := range snap.GPUInfos {
+			attrs := []otlpAttr{{"hw.id", "gpu" + strconv.Itoa(gpu.Index)}, {"hw.name", gpu.Name}, {"hw.type", "gpu"}}
+			util.points = append(util.points, otlpPoint{attrs: attrs, value: gpu.Utilization / 100})
+			memUsage.points = append(memUsage.points, otlpPoint{attrs: attrs, value: gpu.MemoryUsed * 1024 * 1024})
+			memLimit.points = append(memLimit.points, otlpPoint{attrs: attrs, value: gpu.MemoryTotal * 1024 * 1024})
+			temp.points = append(temp.points, otlpPoint{attrs: attrs, value: gpu.Temperature})
+			power.points = append(power.points, otlpPoint{attrs: attrs, value: gpu.PowerUsage})
+		}
+		metrics = append(metrics, util, memUsage, memLimit, temp, power)
+	}
+
+	return metrics
+}
@@ -0,0 +1,248 @@
+package ui
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+func testOTLPSnapshot() *snapshot {
+	return &snapshot{
+		Timestamp:      time.Unix(1700000000, 0),
+		Hostname:       "testhost",
+		Uptime:         time.Hour,
+		CPUPerCore:     []float64{50, 25},
+		Memory:         &mem.VirtualMemoryStat{Total: 1000, Used: 600, Free: 400, UsedPercent: 60},
+		ProcessSummary: processSummary{Total: 10, Running: 2, Sleeping: 7, Zombie: 1},
+		NetBytesSent:   100,
+		NetBytesRecv:   200,
+	}
+}
+
+func TestOTLPExportHTTP(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Errorf("content type = %q", ct)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	exp, err := newOTLPExporter(OTLPOptions{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.export(testOTLPSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	checkOTLPRequest(t, <-bodies)
+}
+
+func TestOTLPExportGRPC(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("proto = %s, want HTTP/2", r.Proto)
+		}
+		if r.URL.Path != otlpGRPCPath {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) < 5 || body[0] != 0 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+			t.Errorf("bad gRPC message prefix % x", body[:5])
+			body = make([]byte, 5)
+		}
+		bodies <- body[5:]
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	exp, err := newOTLPExporter(OTLPOptions{Endpoint: srv.URL, Protocol: otlpProtocolGRPC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.export(testOTLPSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	checkOTLPRequest(t, <-bodies)
+}
+
+func TestOTLPExportGRPCStatus(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "16")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", "unauthenticated")
+	}))
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	defer srv.Close()
+
+	exp, err := newOTLPExporter(OTLPOptions{Endpoint: srv.URL, Protocol: otlpProtocolGRPC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.export(testOTLPSnapshot()); err == nil {
+		t.Error("export succeeded despite a non-zero grpc status")
+	}
+}
+
+// checkOTLPRequest decodes an ExportMetricsServiceRequest and checks it
+// against testOTLPSnapshot.
+func checkOTLPRequest(t *testing.T, body []byte) {
+	t.Helper()
+	req := decodePB(t, body)
+	rms := req[1]
+	if len(rms) != 1 {
+		t.Fatalf("got %d resource metrics, want 1", len(rms))
+	}
+	rm := decodePB(t, rms[0].bytes)
+
+	resource := decodePB(t, rm[1][0].bytes)
+	attrs := decodeAttrs(t, resource[1])
+	if attrs["host.name"] != "testhost" || attrs["service.name"] != "wtop" {
+		t.Errorf("resource attributes = %v", attrs)
+	}
+
+	type point struct {
+		attrs map[string]string
+		value float64
+	}
+	metrics := map[string][]point{}
+	sums := map[string]bool{}
+	for _, sm := range rm[2] {
+		for _, mf := range decodePB(t, sm.bytes)[2] {
+			m := decodePB(t, mf.bytes)
+			name := string(m[1][0].bytes)
+			data := m[5]
+			if sum := m[7]; len(sum) > 0 {
+				data, sums[name] = sum, true
+			}
+			if len(data) != 1 {
+				t.Fatalf("%s: no gauge or sum", name)
+			}
+			for _, dpf := range decodePB(t, data[0].bytes)[1] {
+				dp := decodePB(t, dpf.bytes)
+				if len(dp[4]) != 1 {
+					t.Fatalf("%s: data point without a double value", name)
+				}
+				metrics[name] = append(metrics[name], point{
+					attrs: decodeAttrs(t, dp[7]),
+					value: math.Float64frombits(dp[4][0].num),
+				})
+			}
+		}
+	}
+
+	for _, name := range []string{"system.cpu.utilization", "system.memory.usage", "system.memory.limit", "system.network.io", "system.process.count"} {
+		if _, ok := metrics[name]; !ok {
+			t.Errorf("metric %s missing", name)
+		}
+	}
+	if _, ok := metrics["system.cpu.load_average.1m"]; ok {
+		t.Error("load average exported although it was not reported")
+	}
+
+	cpu := metrics["system.cpu.utilization"]
+	if len(cpu) != 2 || cpu[0].value != 0.5 || cpu[1].value != 0.25 || cpu[1].attrs["system.cpu.logical_number"] != "1" {
+		t.Errorf("system.cpu.utilization = %+v", cpu)
+	}
+	for _, p := range metrics["system.memory.usage"] {
+		if p.attrs["system.memory.state"] == "used" && p.value != 600 {
+			t.Errorf("used memory = %v, want 600", p.value)
+		}
+	}
+	if !sums["system.memory.usage"] || sums["system.cpu.utilization"] {
+		t.Errorf("sum metrics = %v", sums)
+	}
+
+	var total float64
+	for _, p := range metrics["system.process.count"] {
+		if p.attrs["process.status"] == "" {
+			t.Errorf("process count point without a status: %+v", p)
+		}
+		total += p.value
+	}
+	if total != 10 {
+		t.Errorf("process counts sum to %v, want 10", total)
+	}
+}
+
+type pbField struct {
+	num   uint64
+	bytes []byte
+}
+
+// decodePB splits a protobuf message into its fields by number. Varint and
+// fixed64 values are returned in num, length-delimited ones in bytes.
+func decodePB(t *testing.T, b []byte) map[int][]pbField {
+	t.Helper()
+	fields := map[int][]pbField{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("bad tag")
+		}
+		b = b[n:]
+		var f pbField
+		switch key & 7 {
+		case 0:
+			f.num, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatalf("bad varint")
+			}
+			b = b[n:]
+		case 1:
+			if len(b) < 8 {
+				t.Fatalf("short fixed64")
+			}
+			f.num, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				t.Fatalf("bad length-delimited field")
+			}
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields[int(key>>3)] = append(fields[int(key>>3)], f)
+	}
+	return fields
+}
+
+// decodeAttrs decodes KeyValue messages with string values.
+func decodeAttrs(t *testing.T, kvs []pbField) map[string]string {
+	t.Helper()
+	attrs := map[string]string{}
+	for _, f := range kvs {
+		kv := decodePB(t, f.bytes)
+		if len(kv[1]) != 1 || len(kv[2]) != 1 {
+			t.Fatalf("malformed KeyValue %s", fmt.Sprint(kv))
+		}
+		value := decodePB(t, kv[2][0].bytes)
+		var s string
+		if len(value[1]) > 0 {
+			s = string(value[1][0].bytes)
+		}
+		attrs[string(kv[1][0].bytes)] = s
+	}
+	return attrs
+}
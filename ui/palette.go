@@ -0,0 +1,532 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// maxPaletteEntries caps the completion list so it fits above the footer.
+const maxPaletteEntries = 12
+
+// paletteCommand is a command of the ':' palette. Every action of the
+// keymap is a command as well, under its own name.
+type paletteCommand struct {
+	name string
+	args string // synopsis of the arguments, "" for none
+	help string
+	// variadic commands take any number of arguments, so completing one
+	// leaves the line open for the next.
+	variadic bool
+	// complete returns the choices for argument i, given the ones before.
+	complete func(d *Dashboard, i int, args []string) []paletteSuggestion
+	run      func(d *Dashboard, args []string) error
+}
+
+// paletteSuggestion is one entry of the completion list: the text it puts
+// on the line and a short description shown next to it.
+type paletteSuggestion struct {
+	text, note string
+}
+
+// paletteCommands is filled in by init for the same reason as keyActions.
+var paletteCommands []*paletteCommand
+
+func init() {
+	paletteCommands = []*paletteCommand{
+		{name: "sort", args: "[cpu|mem|time]", help: "Sort the process table, or cycle without a mode",
+			complete: choices(SortNames),
+			run: func(d *Dashboard, args []string) error {
+				if len(args) == 0 {
+					d.cycleSortMode()
+					return nil
+				}
+				mode, ok := sortNames[strings.ToLower(args[0])]
+				if !ok {
+					return fmt.Errorf("unknown sort mode %q (want cpu, mem or time)", args[0])
+				}
+				d.sortMode = mode
+				if d.lastSnapshot != nil {
+					d.updateProcessTable(d.lastSnapshot)
+					d.updateFooter(d.lastSnapshot, d.lastRates)
+				}
+				return nil
+			}},
+		{name: "filter", args: "[text]", help: "Filter processes, or clear the filter",
+			run: func(d *Dashboard, args []string) error {
+				d.filterInput.SetText(strings.Join(args, " "))
+				return nil
+			}},
+		{name: "refresh", args: "<interval>", help: "Set the refresh interval, such as 500ms or 5s",
+			complete: choices(func() []string { return []string{"500ms", "1s", "2s", "5s", "10s", "30s"} }),
+			run:      (*Dashboard).setRefresh},
+		{name: "panel", args: "<name>", help: "Show or hide a panel",
+			complete: func(d *Dashboard, i int, _ []string) []paletteSuggestion {
+				if i > 0 {
+					return nil
+				}
+				var out []paletteSuggestion
+				for _, name := range panelNames {
+					note := "hidden"
+					if containsString(d.layout.Panels, name) {
+						note = "shown"
+					}
+					out = append(out, paletteSuggestion{text: name, note: note})
+				}
+				return out
+			},
+			run: func(d *Dashboard, args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: panel <name>")
+				}
+				return d.togglePanel(args[0])
+			}},
+		{name: "columns", args: "[name...]", help: "Pick the process columns, or fit them to the width", variadic: true,
+			complete: func(_ *Dashboard, _ int, args []string) []paletteSuggestion {
+				var out []paletteSuggestion
+				for _, name := range columnNames {
+					if !containsString(args, name) {
+						out = append(out, paletteSuggestion{text: name})
+					}
+				}
+				return out
+			},
+			run: func(d *Dashboard, args []string) error {
+				if problems := validateNames("columns", args, columnNames); len(problems) > 0 {
+					return fmt.Errorf("%s", problems[0])
+				}
+				activeConfig.Columns = args
+				if d.lastSnapshot != nil {
+					d.updateProcessTable(d.lastSnapshot)
+				}
+				return nil
+			}},
+		{name: "theme", args: "<name>", help: "Switch the color theme",
+			complete: choices(func() []string {
+				names := ThemeNames()
+				var user []string
+				for name := range activeConfig.Themes {
+					user = append(user, name)
+				}
+				sort.Strings(user)
+				return append(names, user...)
+			}),
+			run: func(d *Dashboard, args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: theme <name>")
+				}
+				return d.switchTheme(args[0])
+			}},
+		{name: "signal", args: "<pid> [TERM|KILL]", help: "Send a signal to any process, after confirmation",
+			complete: func(d *Dashboard, i int, _ []string) []paletteSuggestion {
+				if i == 1 {
+					return []paletteSuggestion{{text: "TERM"}, {text: "KILL"}}
+				}
+				if i > 1 {
+					return nil
+				}
+				out := make([]paletteSuggestion, 0, len(d.tableProcs))
+				for _, p := range d.tableProcs {
+					out = append(out, paletteSuggestion{text: strconv.Itoa(int(p.PID)), note: p.Name})
+				}
+				return out
+			},
+			run: func(d *Dashboard, args []string) error {
+				if len(args) < 1 || len(args) > 2 {
+					return fmt.Errorf("usage: signal <pid> [TERM|KILL]")
+				}
+				pid, err := strconv.ParseInt(args[0], 10, 32)
+				if err != nil || pid <= 0 {
+					return fmt.Errorf("%q is not a PID", args[0])
+				}
+				signal := "TERM"
+				if len(args) == 2 {
+					signal = strings.TrimPrefix(strings.ToUpper(args[1]), "SIG")
+				}
+				if signal != "TERM" && signal != "KILL" {
+					return fmt.Errorf("unsupported signal %q (want TERM or KILL)", args[1])
+				}
+				return d.requestSignalPID(int32(pid), signal)
+			}},
+		{name: "record", args: "[file]", help: "Start recording for wtop replay, or stop",
+			run: func(d *Dashboard, args []string) error {
+				if d.recording != nil && len(args) == 0 {
+					return d.stopRecording()
+				}
+				path := fmt.Sprintf("wtop-%s.wtop", time.Now().Format("20060102-150405"))
+				if len(args) > 0 {
+					path = args[0]
+				}
+				if err := d.startRecording(path); err != nil {
+					return err
+				}
+				d.setNotice("Recording to " + path + ", :record again stops")
+				return nil
+			}},
+		{name: "export", args: "[file]", help: "Save the snapshot on screen as JSON",
+			run: func(d *Dashboard, args []string) error {
+				path := fmt.Sprintf("wtop-snapshot-%s.json", time.Now().Format("20060102-150405"))
+				if len(args) > 0 {
+					path = args[0]
+				}
+				return d.exportSnapshot(path)
+			}},
+		{name: "tab", args: "<name>", help: "Switch to a tab",
+			complete: choices(func() []string {
+				names := make([]string, len(tabNames))
+				for i, name := range tabNames {
+					names[i] = strings.ToLower(name)
+				}
+				return names
+			}),
+			run: func(d *Dashboard, args []string) error {
+				for i, name := range tabNames {
+					if len(args) == 1 && strings.EqualFold(args[0], name) {
+						d.switchTab(tab(i))
+						return nil
+					}
+				}
+				return fmt.Errorf("usage: tab <%s>", strings.ToLower(strings.Join(tabNames, "|")))
+			}},
+		{name: "layout", args: "<name>", help: "Switch to a layout from the config file",
+			complete: choices(func() []string { return append([]string{"default"}, activeConfig.LayoutNames()...) }),
+			run: func(d *Dashboard, args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("usage: layout <name>")
+				}
+				name := args[0]
+				if name == "default" {
+					name = ""
+				} else if _, ok := activeConfig.Layouts[name]; !ok {
+					return fmt.Errorf("no layout named %q", name)
+				}
+				d.useLayout(name)
+				return nil
+			}},
+	}
+}
+
+// choices completes the first argument from a fixed list.
+func choices(list func() []string) func(*Dashboard, int, []string) []paletteSuggestion {
+	return func(_ *Dashboard, i int, _ []string) []paletteSuggestion {
+		if i > 0 {
+			return nil
+		}
+		var out []paletteSuggestion
+		for _, s := range list() {
+			out = append(out, paletteSuggestion{text: s})
+		}
+		return out
+	}
+}
+
+// allPaletteCommands is the palette's commands followed by the keymap's
+// actions that are not already commands, such as zoom or save_layout.
+func (d *Dashboard) allPaletteCommands() []*paletteCommand {
+	all := append([]*paletteCommand(nil), paletteCommands...)
+	for _, a := range keyActions {
+		a := a
+		if a.run == nil || a.name == "palette" || findPaletteCommand(all, a.name) != nil {
+			continue
+		}
+		help := a.help
+		if label := d.keymap.label(a.name); label != "" {
+			help += " (" + label + ")"
+		}
+		all = append(all, &paletteCommand{name: a.name, help: help, run: func(d *Dashboard, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("%s takes no arguments", a.name)
+			}
+			if a.when != nil && !a.when(d) {
+				return fmt.Errorf("%s is not available now", a.name)
+			}
+			a.run(d)
+			return nil
+		}})
+	}
+	return all
+}
+
+func findPaletteCommand(commands []*paletteCommand, name string) *paletteCommand {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// fuzzyScore reports whether the characters of query appear in s in order,
+// ignoring case, and how well they match: consecutive characters and ones
+// at the start of a word count for more, and shorter candidates win ties.
+func fuzzyScore(query, s string) (int, bool) {
+	query, s = strings.ToLower(query), strings.ToLower(s)
+	score, last := 0, -2
+	qi := 0
+	for i := 0; i < len(s) && qi < len(query); i++ {
+		if s[i] != query[qi] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 2
+		}
+		if i == 0 || strings.IndexByte("_- ./", s[i-1]) >= 0 {
+			score += 3
+		}
+		last = i
+		qi++
+	}
+	if qi < len(query) {
+		return 0, false
+	}
+	return score*100 - len(s), true
+}
+
+// fuzzyFilter keeps the suggestions matching query, best first.
+func fuzzyFilter(query string, suggestions []paletteSuggestion) []paletteSuggestion {
+	if query == "" {
+		return suggestions
+	}
+	type scored struct {
+		s     paletteSuggestion
+		score int
+	}
+	var matches []scored
+	for _, s := range suggestions {
+		if score, ok := fuzzyScore(query, s.text); ok {
+			matches = append(matches, scored{s, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	out := make([]paletteSuggestion, len(matches))
+	for i, m := range matches {
+		out[i] = m.s
+	}
+	return out
+}
+
+// resolvePaletteCommand finds the command named by word, or the only one it
+// is a prefix of, so ":them light" works as well. Fuzzy matches are offered
+// in the completion list but never run unless picked from it.
+func (d *Dashboard) resolvePaletteCommand(word string) (*paletteCommand, error) {
+	all := d.allPaletteCommands()
+	if c := findPaletteCommand(all, word); c != nil {
+		return c, nil
+	}
+	var matches []*paletteCommand
+	for _, c := range all {
+		if strings.HasPrefix(c.name, word) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown command %q", word)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, c := range matches {
+		names[i] = c.name
+	}
+	return nil, fmt.Errorf("%q is ambiguous: %s", word, strings.Join(names, ", "))
+}
+
+// paletteCompletions returns the completion list for the line: commands
+// while the first word is typed, then the choices for the argument under
+// the cursor.
+func (d *Dashboard) paletteCompletions(text string) []paletteSuggestion {
+	words := strings.Fields(text)
+	open := text == "" || strings.HasSuffix(text, " ")
+	var out []paletteSuggestion
+	if len(words) == 0 || (len(words) == 1 && !open) {
+		query := ""
+		if len(words) == 1 {
+			query = words[0]
+		}
+		var all []paletteSuggestion
+		for _, c := range d.allPaletteCommands() {
+			line := c.name
+			if c.args != "" {
+				line += " "
+			}
+			all = append(all, paletteSuggestion{text: line, note: strings.TrimSpace(c.args + "  " + c.help)})
+		}
+		out = fuzzyFilter(query, all)
+	} else {
+		c, err := d.resolvePaletteCommand(words[0])
+		if err != nil || c.complete == nil {
+			return nil
+		}
+		args := words[1:]
+		partial := ""
+		if !open {
+			partial = args[len(args)-1]
+			args = args[:len(args)-1]
+		}
+		prefix := strings.TrimSuffix(text, partial)
+		for _, s := range fuzzyFilter(partial, c.complete(d, len(args), args)) {
+			s.text = prefix + s.text
+			if c.variadic {
+				s.text += " "
+			}
+			out = append(out, s)
+		}
+	}
+	if len(out) > maxPaletteEntries {
+		out = out[:maxPaletteEntries]
+	}
+	return out
+}
+
+// palettePrefixEntry returns the index of the only completion that extends
+// text, or -1.
+func (d *Dashboard) palettePrefixEntry(text string) int {
+	found := -1
+	for i, s := range d.palette {
+		if strings.HasPrefix(s.text, text) {
+			if found >= 0 {
+				return -1
+			}
+			found = i
+		}
+	}
+	return found
+}
+
+func (d *Dashboard) newPaletteInput() *tview.InputField {
+	input := tview.NewInputField().
+		SetLabel(":").
+		SetFieldBackgroundColor(activeTheme.Background).
+		SetLabelColor(activeTheme.Accent)
+	input.SetBackgroundColor(activeTheme.StatusBar)
+	input.SetAutocompleteFunc(func(text string) []string {
+		d.palette = d.paletteCompletions(text)
+		d.paletteNavigated = false
+		entries := make([]string, len(d.palette))
+		for i, s := range d.palette {
+			entries[i] = tview.Escape(s.text)
+			if s.note != "" {
+				entries[i] += "  " + mutedTag() + tview.Escape(s.note) + "[-]"
+			}
+		}
+		return entries
+	})
+	// Tab takes the highlighted entry. Enter takes it only once the user has
+	// moved to it or when it is the only entry extending the typed text;
+	// otherwise the line runs as typed, so a fuzzy match is never run by
+	// accident. Enter also runs the line when the entry completes it.
+	input.SetAutocompletedFunc(func(_ string, index, source int) bool {
+		if source == tview.AutocompletedNavigate {
+			d.paletteNavigated = true
+			return false
+		}
+		if index >= len(d.palette) {
+			return false
+		}
+		if source == tview.AutocompletedEnter && !d.paletteNavigated {
+			typed := input.GetText()
+			exact := findPaletteCommand(d.allPaletteCommands(), strings.TrimSpace(typed)) != nil
+			if index = d.palettePrefixEntry(typed); exact || index < 0 {
+				d.runPalette(typed)
+				return true
+			}
+		}
+		text := d.palette[index].text
+		input.SetText(text)
+		if source == tview.AutocompletedEnter && !strings.HasSuffix(text, " ") {
+			d.runPalette(text)
+			return true
+		}
+		return false
+	})
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			d.runPalette(input.GetText())
+		case tcell.KeyEscape:
+			d.closePalette()
+		}
+	})
+	return input
+}
+
+func (d *Dashboard) openPalette() {
+	d.paletteInput.SetText("")
+	d.root.ResizeItem(d.paletteInput, 1, 0)
+	d.app.SetFocus(d.paletteInput)
+	d.paletteInput.Autocomplete()
+}
+
+func (d *Dashboard) closePalette() {
+	d.root.ResizeItem(d.paletteInput, 0, 0)
+	d.app.SetFocus(d.focusView())
+}
+
+// runPalette closes the palette and runs the command on the line.
+func (d *Dashboard) runPalette(line string) {
+	d.closePalette()
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return
+	}
+	c, err := d.resolvePaletteCommand(words[0])
+	if err != nil {
+		d.setNotice(fmt.Sprintf("%s%v[-]", critTag(), err))
+		return
+	}
+	if err := c.run(d, words[1:]); err != nil {
+		d.setNotice(fmt.Sprintf("%s%s: %v[-]", critTag(), c.name, err))
+	}
+}
+
+func (d *Dashboard) setRefresh(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: refresh <interval>")
+	}
+	if d.ticker == nil {
+		return fmt.Errorf("the interval is set by the recording or the agent")
+	}
+	interval, err := time.ParseDuration(args[0])
+	if err != nil {
+		return err
+	}
+	if interval < 250*time.Millisecond || interval > time.Hour {
+		return fmt.Errorf("%s is outside 250ms..1h", interval)
+	}
+	d.refreshInterval = interval
+	d.ticker.Reset(interval)
+	d.setNotice("Refreshing every " + interval.String())
+	return nil
+}
+
+// exportSnapshot writes the snapshot on screen to path in the format of
+// `wtop snapshot`.
+func (d *Dashboard) exportSnapshot(path string) error {
+	if d.lastSnapshot == nil {
+		return fmt.Errorf("no snapshot yet")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(d.lastSnapshot)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	d.setNotice("Saved snapshot to " + path)
+	return nil
+}
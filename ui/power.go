@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/SwarnenduG07/wtop/metrics"
+)
+
+type cpuPower struct {
+	Package float64
+	DRAM    float64
+	Valid   bool
+}
+
+func (d *Dashboard) computeCPUPower(snap *snapshot) cpuPower {
+	if snap == nil || len(snap.RAPL) == 0 {
+		return cpuPower{}
+	}
+	prev, prevAt := d.prevRAPL, d.prevRAPLTime
+	d.prevRAPL = make(map[string]*metrics.RAPLZone, len(snap.RAPL))
+	for _, zone := range snap.RAPL {
+		d.prevRAPL[zone.ID] = zone
+	}
+	d.prevRAPLTime = snap.Timestamp
+	if prevAt.IsZero() {
+		return cpuPower{}
+	}
+
+	elapsed := snap.Timestamp.Sub(prevAt)
+	if elapsed < 100*time.Millisecond {
+		return cpuPower{}
+	}
+
+	var power cpuPower
+	for _, zone := range snap.RAPL {
+		watts, ok := metrics.RAPLWatts(prev[zone.ID], zone, elapsed)
+		if !ok {
+			continue
+		}
+		switch {
+		case zone.IsPackage():
+			power.Package += watts
+			power.Valid = true
+		case zone.IsDRAM():
+			power.DRAM += watts
+			power.Valid = true
+		}
+	}
+	return power
+}
+
+func (d *Dashboard) formatCPUPower() string {
+	if !d.lastPower.Valid {
+		return ""
+	}
+	line := fmt.Sprintf("Power: pkg %.1fW", d.lastPower.Package)
+	if d.lastPower.DRAM > 0 {
+		line += fmt.Sprintf("  dram %.1fW", d.lastPower.DRAM)
+	}
+	return line
+}
+
+func (d *Dashboard) formatComputePower(snap *snapshot) string {
+	if !d.lastPower.Valid {
+		return ""
+	}
+	total := d.lastPower.Package + d.lastPower.DRAM
+	parts := []string{fmt.Sprintf("CPU %.1fW", d.lastPower.Package)}
+	if d.lastPower.DRAM > 0 {
+		parts = append(parts, fmt.Sprintf("DRAM %.1fW", d.lastPower.DRAM))
+	}
+	if snap != nil {
+		gpuTotal := 0.0
+		for _, gpu := range snap.GPUInfos {
+			gpuTotal += gpu.PowerUsage
+		}
+		if gpuTotal > 0 {
+			parts = append(parts, fmt.Sprintf("GPU %.1fW", gpuTotal))
+			total += gpuTotal
+		}
+	}
+	parts = append(parts, fmt.Sprintf("Total %.1fW", total))
+	return "  Compute power: " + joinWithSpacing(parts)
+}
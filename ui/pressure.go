@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/SwarnenduG07/wtop/metrics"
+)
+
+func psiColor(avg float64) tcell.Color {
+	switch {
+	case avg >= 40:
+		return activeTheme.Critical
+	case avg >= 10:
+		return activeTheme.Warning
+	default:
+		return activeTheme.Good
+	}
+}
+
+func formatPressureAvg(avg metrics.PressureAvg) string {
+	return fmt.Sprintf("%s%s%.2f%s/%.2f/%.2f",
+		markLevel(avg.Avg10 >= 10, avg.Avg10 >= 40),
+		colorTag(psiColor(avg.Avg10)), avg.Avg10, resetTag(), avg.Avg60, avg.Avg300)
+}
+
+func renderPressureLine(name string, p *metrics.Pressure, series []float64, sparkWidth int) string {
+	if p == nil {
+		return ""
+	}
+	line := fmt.Sprintf("PSI %-3s some %s", name, formatPressureAvg(p.Some))
+	if p.HasFull {
+		line += "  full " + formatPressureAvg(p.Full)
+	}
+	if series != nil && sparkWidth >= 8 {
+		line += "  " + renderSparkline(series, sparkWidth)
+	}
+	return line
+}
+
+func (d *Dashboard) renderCgroupPressure() string {
+	pid, ok := d.selectedPID()
+	if !ok || !d.localSource() {
+		return ""
+	}
+	cg, err := metrics.GetCgroupPressure(pid)
+	if err != nil || cg.Memory == nil {
+		return ""
+	}
+	line := fmt.Sprintf("PSI cg  %s mem some %s",
+		truncateLabel(cg.Path, 24), formatPressureAvg(cg.Memory.Some))
+	if cg.CPU != nil {
+		line += "  cpu some " + formatPressureAvg(cg.CPU.Some)
+	}
+	return line
+}
@@ -3,10 +3,13 @@ package ui
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/SwarnenduG07/wtop/metrics"
 	"github.com/SwarnenduG07/wtop/types"
 )
 
@@ -41,39 +44,58 @@ func (d *Dashboard) updateProcessTable(snap *snapshot) {
 		cell   func(*types.ProcessInfo) *tview.TableCell
 	}
 
-	columns := []columnDef{
-		{
+	cmdWidth := 16
+	defs := map[string]columnDef{
+		"pid": {
 			header: "PID",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				return tview.NewTableCell(fmt.Sprintf("%6d", info.PID)).
 					SetAlign(tview.AlignRight).
-					SetTextColor(tcell.ColorLightGray)
+					SetTextColor(activeTheme.Text)
 			},
 		},
-		{
+		"user": {
 			header: "USER",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				return tview.NewTableCell(truncateLabel(info.User, 12)).
-					SetTextColor(tcell.ColorLightGray)
+					SetTextColor(activeTheme.Text)
 			},
 		},
-		{
+		"cpu": {
 			header: "CPU%",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
-				return tview.NewTableCell(fmt.Sprintf("%5.1f", info.CPUPercent)).
+				return tview.NewTableCell(fmt.Sprintf("%5.1f%s", info.CPUPercent, levelMarker(info.CPUPercent))).
 					SetAlign(tview.AlignRight).
 					SetTextColor(usageColor(info.CPUPercent))
 			},
 		},
-		{
+		"mem": {
 			header: "MEM%",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
-				return tview.NewTableCell(fmt.Sprintf("%5.1f", info.MemPercent)).
+				return tview.NewTableCell(fmt.Sprintf("%5.1f%s", info.MemPercent, levelMarker(float64(info.MemPercent)))).
 					SetAlign(tview.AlignRight).
 					SetTextColor(usageColor(float64(info.MemPercent)))
 			},
 		},
-		{
+		"pss": {
+			header: "PSS",
+			cell: func(info *types.ProcessInfo) *tview.TableCell {
+				return memDetailCell(info, info.PSS)
+			},
+		},
+		"uss": {
+			header: "USS",
+			cell: func(info *types.ProcessInfo) *tview.TableCell {
+				return memDetailCell(info, info.USS)
+			},
+		},
+		"swap": {
+			header: "SWAP",
+			cell: func(info *types.ProcessInfo) *tview.TableCell {
+				return memDetailCell(info, info.Swap)
+			},
+		},
+		"gpu": {
 			header: "GPU",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				// check gpuMap for this PID
@@ -81,97 +103,60 @@ func (d *Dashboard) updateProcessTable(snap *snapshot) {
 				if g, ok := gpuMap[pid]; ok {
 					return tview.NewTableCell(fmt.Sprintf("%d:%.0fMB", g.Index, g.Mem)).
 						SetAlign(tview.AlignRight).
-						SetTextColor(tcell.ColorLightCyan)
+						SetTextColor(activeTheme.Accent)
 				}
-				return tview.NewTableCell("").SetTextColor(tcell.ColorGray)
+				return tview.NewTableCell("").SetTextColor(activeTheme.Muted)
 			},
 		},
-		{
+		"state": {
 			header: "STATE",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				return tview.NewTableCell(info.Status).
 					SetAlign(tview.AlignCenter).
-					SetTextColor(tcell.ColorGray)
+					SetTextColor(activeTheme.Muted)
 			},
 		},
-	}
-
-	// If wide enough, show GPU column indicating GPU index and memory used
-	if width >= 100 {
-		columns = append(columns, columnDef{
-			header: "GPU",
-			cell: func(info *types.ProcessInfo) *tview.TableCell {
-				if snap == nil || snap.GPUProcesses == nil {
-					return tview.NewTableCell("")
-				}
-				pid := int(info.PID)
-				for gpuIdx, procs := range snap.GPUProcesses {
-					for _, p := range procs {
-						if p.PID == pid {
-							return tview.NewTableCell(fmt.Sprintf("G%d:%4.0fMB", gpuIdx, p.MemoryUsed)).
-								SetAlign(tview.AlignRight).
-								SetTextColor(tcell.ColorLightCyan)
-						}
-					}
-				}
-				return tview.NewTableCell("")
-			},
-		})
-	}
-
-	if width >= 90 {
-		columns = append(columns, columnDef{
+		"threads": {
 			header: "THR",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				return tview.NewTableCell(fmt.Sprintf("%3d", info.Threads)).
 					SetAlign(tview.AlignRight).
-					SetTextColor(tcell.ColorLightGray)
+					SetTextColor(activeTheme.Text)
 			},
-		})
-	}
-	if width >= 110 {
-		columns = append(columns, columnDef{
+		},
+		"priority": {
 			header: "PRI",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				return tview.NewTableCell(fmt.Sprintf("%3d", info.Priority)).
 					SetAlign(tview.AlignRight).
-					SetTextColor(tcell.ColorLightGray)
+					SetTextColor(activeTheme.Text)
 			},
-		})
-	}
-	if width >= 120 {
-		columns = append(columns, columnDef{
+		},
+		"nice": {
 			header: "NI",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				return tview.NewTableCell(fmt.Sprintf("%3d", info.Nice)).
 					SetAlign(tview.AlignRight).
-					SetTextColor(tcell.ColorLightGray)
+					SetTextColor(activeTheme.Text)
 			},
-		})
-	}
-	if width >= 140 {
-		columns = append(columns,
-			columnDef{
-				header: "VIRT",
-				cell: func(info *types.ProcessInfo) *tview.TableCell {
-					return tview.NewTableCell(formatBytes(float64(info.VirtMem))).
-						SetAlign(tview.AlignRight).
-						SetTextColor(tcell.ColorGray)
-				},
+		},
+		"virt": {
+			header: "VIRT",
+			cell: func(info *types.ProcessInfo) *tview.TableCell {
+				return tview.NewTableCell(formatBytes(float64(info.VirtMem))).
+					SetAlign(tview.AlignRight).
+					SetTextColor(activeTheme.Muted)
 			},
-			columnDef{
-				header: "RES",
-				cell: func(info *types.ProcessInfo) *tview.TableCell {
-					return tview.NewTableCell(formatBytes(float64(info.ResMem))).
-						SetAlign(tview.AlignRight).
-						SetTextColor(tcell.ColorGray)
-				},
-			})
-	}
-
-	cmdWidth := clampInt(width-(len(columns)*10), 16, 48)
-	columns = append(columns,
-		columnDef{
+		},
+		"res": {
+			header: "RES",
+			cell: func(info *types.ProcessInfo) *tview.TableCell {
+				return tview.NewTableCell(formatBytes(float64(info.ResMem))).
+					SetAlign(tview.AlignRight).
+					SetTextColor(activeTheme.Muted)
+			},
+		},
+		"time": {
 			header: "TIME",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
 				runtime := snap.Timestamp.Unix() - info.CreateTime/1000
@@ -180,39 +165,43 @@ func (d *Dashboard) updateProcessTable(snap *snapshot) {
 				}
 				return tview.NewTableCell(formatProcessRuntime(runtime)).
 					SetAlign(tview.AlignRight).
-					SetTextColor(tcell.ColorGray)
+					SetTextColor(activeTheme.Muted)
 			},
 		},
-		columnDef{
+		"command": {
 			header: "COMMAND",
 			cell: func(info *types.ProcessInfo) *tview.TableCell {
-				command := info.Command
-				if command == "" {
-					command = info.Name
-				}
-				return tview.NewTableCell(truncateLabel(command, cmdWidth)).
-					SetTextColor(tcell.ColorLightGray)
+				return tview.NewTableCell(truncateLabel(processDisplayName(info), cmdWidth)).
+					SetTextColor(activeTheme.Text)
 			},
-		})
+		},
+	}
+
+	names := d.processColumns(width)
+	columns := make([]columnDef, len(names))
+	for i, name := range names {
+		columns[i] = defs[name]
+	}
+	cmdWidth = clampInt(width-(len(columns)*10), 16, 48)
 
 	for col, def := range columns {
 		cell := tview.NewTableCell(fmt.Sprintf("[::b]%s", def.header)).
 			SetAlign(tview.AlignLeft).
 			SetSelectable(false).
-			SetTextColor(tcell.ColorLightCyan).
-			SetBackgroundColor(tcell.ColorBlack)
+			SetTextColor(activeTheme.Accent).
+			SetBackgroundColor(activeTheme.Background)
 		table.SetCell(0, col, cell)
 	}
 
 	if snap == nil || len(snap.Processes) == 0 {
-		table.SetCell(1, 0, tview.NewTableCell("[yellow]no process data available[-]").
+		d.tableProcs = nil
+		table.SetCell(1, 0, tview.NewTableCell(warnTag()+"no process data available[-]").
 			SetSelectable(false))
 		table.Select(0, 0)
 		return
 	}
 
-	procs := make([]*types.ProcessInfo, len(snap.Processes))
-	copy(procs, snap.Processes)
+	procs := filterProcesses(snap.Processes, d.filter)
 
 	switch d.sortMode {
 	case SortByMemory:
@@ -238,6 +227,13 @@ func (d *Dashboard) updateProcessTable(snap *snapshot) {
 		maxRows = len(procs)
 	}
 
+	// PSS is read from the local /proc, which says nothing about recorded or
+	// remote processes.
+	if d.localSource() && (d.showPSS || containsString(names, "pss") || containsString(names, "uss") || containsString(names, "swap")) {
+		d.applyMemoryDetail(procs, maxRows)
+	}
+
+	d.tableProcs = procs[:maxRows]
 	for i := 0; i < maxRows; i++ {
 		proc := procs[i]
 		row := i + 1
@@ -246,9 +242,16 @@ func (d *Dashboard) updateProcessTable(snap *snapshot) {
 		}
 	}
 
-	title := fmt.Sprintf(" Processes · sort: %s ", d.sortMode.String())
+	sortLabel := d.sortMode.String()
+	if d.showPSS && d.sortMode == SortByMemory {
+		sortLabel = "PSS"
+	}
+	title := fmt.Sprintf(" Processes%ssort: %s ", glyphs.sep, sortLabel)
+	if d.filter != "" {
+		title = fmt.Sprintf(" Processes%[1]ssort: %[2]s%[1]sfilter: %[3]s (%[4]d) ", glyphs.sep, sortLabel, tview.Escape(d.filter), len(procs))
+	}
 	table.SetTitle(title)
-	table.SetTitleColor(tcell.ColorLightCyan)
+	table.SetTitleColor(activeTheme.Accent)
 
 	if rowCount := table.GetRowCount(); rowCount > 1 {
 		currentRow, currentCol := table.GetSelection()
@@ -259,3 +262,152 @@ func (d *Dashboard) updateProcessTable(snap *snapshot) {
 		}
 	}
 }
+
+// processColumns returns the configured process columns, or by default the
+// columns that fit in width. The PSS toggle adds the memory detail columns
+// when they are not configured already.
+func (d *Dashboard) processColumns(width int) []string {
+	detail := []string{"pss", "uss", "swap"}
+	if configured := activeConfig.Columns; len(configured) > 0 {
+		if !d.showPSS || containsString(configured, "pss") {
+			return configured
+		}
+		names := make([]string, 0, len(configured)+len(detail))
+		added := false
+		for _, name := range configured {
+			names = append(names, name)
+			if name == "mem" {
+				names = append(names, detail...)
+				added = true
+			}
+		}
+		if !added {
+			names = append(names, detail...)
+		}
+		return names
+	}
+
+	names := []string{"pid", "user", "cpu", "mem"}
+	if d.showPSS {
+		names = append(names, detail...)
+	}
+	if activeConfig.GPU {
+		names = append(names, "gpu")
+	}
+	names = append(names, "state")
+	if width >= 90 {
+		names = append(names, "threads")
+	}
+	if width >= 110 {
+		names = append(names, "priority")
+	}
+	if width >= 120 {
+		names = append(names, "nice")
+	}
+	if width >= 140 {
+		names = append(names, "virt", "res")
+	}
+	return append(names, "time", "command")
+}
+
+// processDisplayName is the command line, or the name when it is unknown,
+// with the configured naming rules applied.
+func processDisplayName(info *types.ProcessInfo) string {
+	command := info.Command
+	if command == "" {
+		command = info.Name
+	}
+	return activeConfig.displayName(command)
+}
+
+// filterProcesses returns a copy of procs holding those allowed by the PID
+// and user restrictions whose PID, user, name or displayed command contains
+// filter, ignoring case.
+func filterProcesses(procs []*types.ProcessInfo, filter string) []*types.ProcessInfo {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	out := make([]*types.ProcessInfo, 0, len(procs))
+	for _, info := range procs {
+		if !activeConfig.showsProcess(info.PID, info.User) {
+			continue
+		}
+		if filter == "" ||
+			strings.Contains(strconv.Itoa(int(info.PID)), filter) ||
+			strings.Contains(strings.ToLower(info.User), filter) ||
+			strings.Contains(strings.ToLower(info.Name), filter) ||
+			strings.Contains(strings.ToLower(processDisplayName(info)), filter) {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+func (d *Dashboard) startFilter() {
+	d.filterInput.SetText(d.filter)
+	d.root.ResizeItem(d.filterInput, 1, 0)
+	d.app.SetFocus(d.filterInput)
+}
+
+func (d *Dashboard) setFilter(text string) {
+	d.filter = text
+	if d.lastSnapshot != nil {
+		d.updateProcessTable(d.lastSnapshot)
+	}
+}
+
+// finishFilter closes the filter prompt. Enter keeps the filter; Escape
+// clears it.
+func (d *Dashboard) finishFilter(key tcell.Key) {
+	if key == tcell.KeyEscape {
+		d.filterInput.SetText("")
+	}
+	d.root.ResizeItem(d.filterInput, 0, 0)
+	d.app.SetFocus(d.focusView())
+}
+
+func (d *Dashboard) selectedPID() (int32, bool) {
+	row, _ := d.processTable.GetSelection()
+	if row <= 0 || row > len(d.tableProcs) {
+		return 0, false
+	}
+	return d.tableProcs[row-1].PID, true
+}
+
+// applyMemoryDetail reads PSS/USS/swap for the rows about to be shown. For the
+// memory sort it reads a wider window of RSS-ranked candidates and re-ranks
+// them by PSS; since PSS never exceeds RSS, the real top rows are almost
+// always among them.
+func (d *Dashboard) applyMemoryDetail(procs []*types.ProcessInfo, rows int) {
+	window := rows
+	if d.sortMode == SortByMemory {
+		window = rows * 2
+	}
+	if window > len(procs) {
+		window = len(procs)
+	}
+	for _, info := range procs[:window] {
+		metrics.FillMemoryDetail(info)
+	}
+	if d.sortMode == SortByMemory {
+		head := procs[:window]
+		sort.SliceStable(head, func(i, j int) bool {
+			return memorySortKey(head[i]) > memorySortKey(head[j])
+		})
+	}
+}
+
+func memorySortKey(info *types.ProcessInfo) uint64 {
+	if info.MemDetail {
+		return info.PSS
+	}
+	return info.ResMem
+}
+
+func memDetailCell(info *types.ProcessInfo, value uint64) *tview.TableCell {
+	text := "-"
+	if info.MemDetail {
+		text = formatBytes(float64(value))
+	}
+	return tview.NewTableCell(text).
+		SetAlign(tview.AlignRight).
+		SetTextColor(activeTheme.Text)
+}
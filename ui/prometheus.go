@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxExportedProcesses = 50
+
+// promExporter serves the latest snapshot in the Prometheus text exposition
+// format. The dashboard and the headless serve mode both feed it, so scraped
+// values always match what is on screen.
+type promExporter struct {
+	mu   sync.RWMutex
+	snap *snapshot
+	topN int
+}
+
+func newPromExporter(topN int) *promExporter {
+	if topN > maxExportedProcesses {
+		topN = maxExportedProcesses
+	}
+	return &promExporter{topN: topN}
+}
+
+func (e *promExporter) push(snap *snapshot) {
+	if snap == nil {
+		return
+	}
+	e.mu.Lock()
+	e.snap = snap
+	e.mu.Unlock()
+}
+
+func (e *promExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	snap := e.snap
+	e.mu.RUnlock()
+	if snap == nil {
+		http.Error(w, "no snapshot collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writePromMetrics(w, snap, e.topN)
+}
+
+func (e *promExporter) listen(addr string) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `wtop exporter: metrics are at /metrics`)
+	})
+	// Listening first surfaces bind errors (port in use) to the caller.
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go srv.Serve(ln)
+	return srv, nil
+}
+
+// EnablePrometheus exposes the dashboard's snapshots on addr at /metrics
+// alongside the terminal UI.
+func (d *Dashboard) EnablePrometheus(addr string, topN int) error {
+	exp := newPromExporter(topN)
+	if _, err := exp.listen(addr); err != nil {
+		return err
+	}
+	d.sinks = append(d.sinks, exp)
+	return nil
+}
+
+type promWriter struct {
+	w io.Writer
+}
+
+func (p promWriter) family(name, help, kind string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p promWriter) sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(promEscape(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+	io.WriteString(p.w, b.String())
+}
+
+func promEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func writePromMetrics(w io.Writer, snap *snapshot, topN int) {
+	p := promWriter{w: w}
+
+	p.family("wtop_cpu_usage_percent", "CPU utilization per logical core.", "gauge")
+	for i, v := range snap.CPUPerCore {
+		p.sample("wtop_cpu_usage_percent", v, "cpu", strconv.Itoa(i))
+	}
+	p.family("wtop_cpu_usage_total_percent", "Overall CPU utilization.", "gauge")
+	p.sample("wtop_cpu_usage_total_percent", snap.TotalCPU)
+
+	if snap.LoadReported {
+		p.family("wtop_load_average", "System load average.", "gauge")
+		p.sample("wtop_load_average", snap.Load1, "period", "1m")
+		p.sample("wtop_load_average", snap.Load5, "period", "5m")
+		p.sample("wtop_load_average", snap.Load15, "period", "15m")
+	}
+
+	if m := snap.Memory; m != nil {
+		p.family("wtop_memory_bytes", "Physical memory by state.", "gauge")
+		p.sample("wtop_memory_bytes", float64(m.Total), "state", "total")
+		p.sample("wtop_memory_bytes", float64(m.Used), "state", "used")
+		p.sample("wtop_memory_bytes", float64(m.Available), "state", "available")
+		p.sample("wtop_memory_bytes", float64(m.Cached), "state", "cached")
+		p.sample("wtop_memory_bytes", float64(m.Buffers), "state", "buffers")
+	}
+	if s := snap.Swap; s != nil {
+		p.family("wtop_swap_bytes", "Swap space by state.", "gauge")
+		p.sample("wtop_swap_bytes", float64(s.Total), "state", "total")
+		p.sample("wtop_swap_bytes", float64(s.Used), "state", "used")
+	}
+
+	if len(snap.Disks) > 0 {
+		p.family("wtop_disk_bytes", "Filesystem space by mountpoint and state.", "gauge")
+		for _, d := range snap.Disks {
+			p.sample("wtop_disk_bytes", float64(d.Total), "mountpoint", d.Path, "fstype", d.Fstype, "state", "total")
+			p.sample("wtop_disk_bytes", float64(d.Used), "mountpoint", d.Path, "fstype", d.Fstype, "state", "used")
+		}
+	}
+
+	p.family("wtop_network_bytes_total", "Bytes transferred over all interfaces.", "counter")
+	p.sample("wtop_network_bytes_total", float64(snap.NetBytesSent), "direction", "sent")
+	p.sample("wtop_network_bytes_total", float64(snap.NetBytesRecv), "direction", "received")
+
+	if len(snap.GPUInfos) > 0 {
+		gpuFamilies := []struct {
+			name, help string
+			value      func(i int) float64
+		}{
+			{"wtop_gpu_utilization_percent", "GPU core utilization.", func(i int) float64 { return snap.GPUInfos[i].Utilization }},
+			{"wtop_gpu_memory_used_bytes", "GPU memory in use.", func(i int) float64 { return snap.GPUInfos[i].MemoryUsed * 1024 * 1024 }},
+			{"wtop_gpu_memory_total_bytes", "GPU memory size.", func(i int) float64 { return snap.GPUInfos[i].MemoryTotal * 1024 * 1024 }},
+			{"wtop_gpu_temperature_celsius", "GPU core temperature.", func(i int) float64 { return snap.GPUInfos[i].Temperature }},
+			{"wtop_gpu_power_watts", "GPU power draw.", func(i int) float64 { return snap.GPUInfos[i].PowerUsage }},
+		}
+		for _, fam := range gpuFamilies {
+			p.family(fam.name, fam.help, "gauge")
+			for i, gpu := range snap.GPUInfos {
+				p.sample(fam.name, fam.value(i), "gpu", strconv.Itoa(gpu.Index), "name", gpu.Name)
+			}
+		}
+	}
+
+	p.family("wtop_processes", "Number of processes by state.", "gauge")
+	p.sample("wtop_processes", float64(snap.ProcessSummary.Total), "state", "total")
+	p.sample("wtop_processes", float64(snap.ProcessSummary.Running), "state", "running")
+
+	// Top-N processes are labeled by rank only, not by PID or name, so the
+	// number of series stays bounded by topN no matter how many processes
+	// come and go.
+	if topN > 0 && len(snap.Processes) > 0 {
+		procs := snap.Processes
+		if len(procs) > topN {
+			procs = procs[:topN]
+		}
+		p.family("wtop_top_process_cpu_percent", "CPU utilization of the busiest processes.", "gauge")
+		for i, proc := range procs {
+			p.sample("wtop_top_process_cpu_percent", proc.CPUPercent, "rank", strconv.Itoa(i+1))
+		}
+		p.family("wtop_top_process_resident_bytes", "Resident memory of the busiest processes.", "gauge")
+		for i, proc := range procs {
+			p.sample("wtop_top_process_resident_bytes", float64(proc.ResMem), "rank", strconv.Itoa(i+1))
+		}
+	}
+}
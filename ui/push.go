@@ -0,0 +1,415 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxDatagram keeps UDP payloads below a typical Ethernet MTU so packets are
+// not fragmented on their way to Telegraf or a StatsD daemon.
+const maxDatagram = 1400
+
+// maxInfluxBuffer bounds the line protocol kept for InfluxDB while writes
+// are failing.
+const maxInfluxBuffer = 8 << 20
+
+// PushOptions configures the InfluxDB line protocol and StatsD sinks. Tags
+// are added to every point; FlushInterval batches InfluxDB writes and
+// throttles StatsD gauges, sending on every snapshot when zero.
+type PushOptions struct {
+	InfluxURL     string
+	InfluxToken   string
+	StatsDAddr    string
+	Prefix        string
+	Tags          map[string]string
+	FlushInterval time.Duration
+}
+
+type pushField struct {
+	name  string
+	value float64
+}
+
+type pushSample struct {
+	measurement string
+	tags        [][2]string
+	fields      []pushField
+}
+
+// pushSamples flattens a snapshot into measurement/tag/field triples shared by
+// the InfluxDB and StatsD encoders.
+func pushSamples(snap *snapshot) []pushSample {
+	samples := []pushSample{{
+		measurement: "cpu",
+		tags:        [][2]string{{"cpu", "total"}},
+		fields:      []pushField{{"usage_percent", snap.TotalCPU}},
+	}}
+	for i, v := range snap.CPUPerCore {
+		samples = append(samples, pushSample{
+			measurement: "cpu",
+			tags:        [][2]string{{"cpu", strconv.Itoa(i)}},
+			fields:      []pushField{{"usage_percent", v}},
+		})
+	}
+	if snap.LoadReported {
+		samples = append(samples, pushSample{measurement: "load", fields: []pushField{
+			{"load1", snap.Load1}, {"load5", snap.Load5}, {"load15", snap.Load15},
+		}})
+	}
+	if m := snap.Memory; m != nil {
+		samples = append(samples, pushSample{measurement: "mem", fields: []pushField{
+			{"total", float64(m.Total)}, {"used", float64(m.Used)}, {"available", float64(m.Available)},
+			{"cached", float64(m.Cached)}, {"buffers", float64(m.Buffers)}, {"used_percent", m.UsedPercent},
+		}})
+	}
+	if s := snap.Swap; s != nil {
+		samples = append(samples, pushSample{measurement: "swap", fields: []pushField{
+			{"total", float64(s.Total)}, {"used", float64(s.Used)}, {"used_percent", s.UsedPercent},
+		}})
+	}
+	for _, d := range snap.Disks {
+		samples = append(samples, pushSample{
+			measurement: "disk",
+			tags:        [][2]string{{"path", d.Path}},
+			fields: []pushField{
+				{"total", float64(d.Total)}, {"used", float64(d.Used)}, {"used_percent", d.UsedPercent},
+			},
+		})
+	}
+	samples = append(samples, pushSample{measurement: "net", fields: []pushField{
+		{"bytes_sent", float64(snap.NetBytesSent)}, {"bytes_recv", float64(snap.NetBytesRecv)},
+	}})
+	for _, gpu := range snap.GPUInfos {
+		samples = append(samples, pushSample{
+			measurement: "gpu",
+			tags:        [][2]string{{"gpu", strconv.Itoa(gpu.Index)}, {"name", gpu.Name}},
+			fields: []pushField{
+				{"utilization_percent", gpu.Utilization},
+				{"memory_used_mb", gpu.MemoryUsed},
+				{"memory_total_mb", gpu.MemoryTotal},
+				{"temperature_c", gpu.Temperature},
+				{"power_w", gpu.PowerUsage},
+			},
+		})
+	}
+	samples = append(samples, pushSample{measurement: "processes", fields: []pushField{
+		{"total", float64(snap.ProcessSummary.Total)},
+		{"running", float64(snap.ProcessSummary.Running)},
+		{"threads", float64(snap.ProcessSummary.Threads)},
+	}})
+	return samples
+}
+
+// flushGate reports whether enough time has passed since the last flush.
+type flushGate struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func (g *flushGate) due(now time.Time) bool {
+	if g.interval <= 0 || g.last.IsZero() || now.Sub(g.last) >= g.interval {
+		g.last = now
+		return true
+	}
+	return false
+}
+
+type influxSink struct {
+	opts   PushOptions
+	url    *url.URL
+	client *http.Client
+	gate   flushGate
+	buf    bytes.Buffer
+}
+
+func newInfluxSink(opts PushOptions) (*influxSink, error) {
+	u, err := url.Parse(opts.InfluxURL)
+	if err != nil {
+		return nil, fmt.Errorf("influx url: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "udp":
+	default:
+		return nil, fmt.Errorf("influx url %q: scheme must be http, https or udp", opts.InfluxURL)
+	}
+	return &influxSink{
+		opts:   opts,
+		url:    u,
+		client: &http.Client{Timeout: 10 * time.Second},
+		gate:   flushGate{interval: opts.FlushInterval},
+	}, nil
+}
+
+// send appends the snapshots to the pending batch and writes the batch when
+// the flush interval has passed. A batch that could not be written is kept
+// and sent again with the next flush, so a short outage loses no points.
+func (s *influxSink) send(snaps []*snapshot) error {
+	for _, snap := range snaps {
+		s.write(snap)
+	}
+	s.trim()
+	if !s.gate.due(snaps[len(snaps)-1].Timestamp) {
+		return nil
+	}
+	err := s.flush()
+	var rejected *influxRejected
+	if err == nil || errors.As(err, &rejected) {
+		// Points the server refused will not be accepted on a retry either.
+		s.buf.Reset()
+	}
+	return err
+}
+
+func (s *influxSink) flush() error {
+	if s.url.Scheme == "udp" {
+		return sendDatagrams(s.url.Host, s.buf.Bytes())
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url.String(), bytes.NewReader(s.buf.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.opts.InfluxToken != "" {
+		req.Header.Set("Authorization", "Token "+s.opts.InfluxToken)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return &influxRejected{status: resp.Status}
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("influx write: %s", resp.Status)
+	}
+	return nil
+}
+
+// influxRejected is a client error from the write endpoint, such as a
+// malformed point or a bad token.
+type influxRejected struct {
+	status string
+}
+
+func (e *influxRejected) Error() string {
+	return "influx write: " + e.status
+}
+
+// trim drops the oldest lines once the retained batch passes
+// maxInfluxBuffer, bounding memory during a long outage.
+func (s *influxSink) trim() {
+	excess := s.buf.Len() - maxInfluxBuffer
+	if excess <= 0 {
+		return
+	}
+	data := s.buf.Bytes()
+	cut := bytes.IndexByte(data[excess:], '\n')
+	if cut < 0 {
+		s.buf.Reset()
+		return
+	}
+	rest := append([]byte(nil), data[excess+cut+1:]...)
+	s.buf.Reset()
+	s.buf.Write(rest)
+}
+
+// write appends the snapshot's points to the batch as line protocol.
+func (s *influxSink) write(snap *snapshot) {
+	host := snap.Hostname
+	ts := strconv.FormatInt(snap.Timestamp.UnixNano(), 10)
+	for _, sample := range pushSamples(snap) {
+		s.buf.WriteString(influxEscape(s.opts.Prefix+sample.measurement, ", "))
+		for _, tag := range mergeTags(host, s.opts.Tags, sample.tags) {
+			s.buf.WriteByte(',')
+			s.buf.WriteString(influxEscape(tag[0], ",= "))
+			s.buf.WriteByte('=')
+			s.buf.WriteString(influxEscape(tag[1], ",= "))
+		}
+		for i, f := range sample.fields {
+			if i == 0 {
+				s.buf.WriteByte(' ')
+			} else {
+				s.buf.WriteByte(',')
+			}
+			s.buf.WriteString(influxEscape(f.name, ",= "))
+			s.buf.WriteByte('=')
+			s.buf.WriteString(strconv.FormatFloat(f.value, 'f', -1, 64))
+		}
+		s.buf.WriteByte(' ')
+		s.buf.WriteString(ts)
+		s.buf.WriteByte('\n')
+	}
+}
+
+func influxEscape(value, special string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if strings.ContainsRune(special, r) || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type statsdSink struct {
+	opts PushOptions
+	gate flushGate
+}
+
+func newStatsDSink(opts PushOptions) *statsdSink {
+	return &statsdSink{opts: opts, gate: flushGate{interval: opts.FlushInterval}}
+}
+
+// send writes every field as a gauge named prefix.measurement.tagvalues.field.
+// Global tags use the DogStatsD "|#key:value" extension since plain StatsD has
+// no notion of tags.
+func (s *statsdSink) send(snap *snapshot) error {
+	if !s.gate.due(snap.Timestamp) {
+		return nil
+	}
+
+	suffix := ""
+	if len(s.opts.Tags) > 0 {
+		var tags []string
+		for _, tag := range mergeTags("", s.opts.Tags, nil) {
+			tags = append(tags, statsdName(tag[0])+":"+statsdName(tag[1]))
+		}
+		suffix = "|#" + strings.Join(tags, ",")
+	}
+
+	var buf bytes.Buffer
+	for _, sample := range pushSamples(snap) {
+		path := []string{sample.measurement}
+		for _, tag := range sample.tags {
+			if tag[0] == "name" {
+				continue
+			}
+			path = append(path, statsdName(tag[1]))
+		}
+		base := s.opts.Prefix + strings.Join(path, ".")
+		for _, f := range sample.fields {
+			fmt.Fprintf(&buf, "%s.%s:%s|g%s\n", base, f.name, strconv.FormatFloat(f.value, 'f', -1, 64), suffix)
+		}
+	}
+	return sendDatagrams(s.opts.StatsDAddr, buf.Bytes())
+}
+
+func statsdName(value string) string {
+	if strings.Trim(value, "/") == "" {
+		return "root"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', ' ', '/', '\\':
+			return '_'
+		}
+		return r
+	}, strings.Trim(value, "/"))
+}
+
+// mergeTags combines the host, global and per-sample tags, leaving out empty
+// values such as a GPU without a name, which line protocol does not allow.
+func mergeTags(host string, global map[string]string, local [][2]string) [][2]string {
+	var tags [][2]string
+	if host != "" {
+		if _, ok := global["host"]; !ok {
+			tags = append(tags, [2]string{"host", host})
+		}
+	}
+	keys := make([]string, 0, len(global))
+	for k := range global {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if global[k] != "" {
+			tags = append(tags, [2]string{k, global[k]})
+		}
+	}
+	for _, tag := range local {
+		if tag[1] != "" {
+			tags = append(tags, tag)
+		}
+	}
+	// Line protocol requires tags sorted by key for best write performance.
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i][0] < tags[j][0] })
+	return tags
+}
+
+// sendDatagrams splits newline-separated lines into UDP packets that stay
+// under maxDatagram bytes.
+func sendDatagrams(addr string, payload []byte) error {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for len(payload) > 0 {
+		n := len(payload)
+		if n > maxDatagram {
+			n = bytes.LastIndexByte(payload[:maxDatagram], '\n') + 1
+			if n <= 0 {
+				n = bytes.IndexByte(payload, '\n') + 1
+				if n <= 0 {
+					n = len(payload)
+				}
+			}
+		}
+		if _, err := conn.Write(payload[:n]); err != nil {
+			return err
+		}
+		payload = payload[n:]
+	}
+	return nil
+}
+
+// pushSinks builds the InfluxDB and StatsD sinks configured in opts. When
+// logged is set, failed sends are also written to the log for serve mode.
+func pushSinks(opts PushOptions, logged bool) ([]snapshotSink, error) {
+	sends := map[string]func([]*snapshot) error{}
+	if opts.InfluxURL != "" {
+		influx, err := newInfluxSink(opts)
+		if err != nil {
+			return nil, err
+		}
+		sends["influx"] = influx.send
+	}
+	if opts.StatsDAddr != "" {
+		sends["statsd"] = eachSnapshot(newStatsDSink(opts).send)
+	}
+
+	var sinks []snapshotSink
+	for _, name := range []string{"influx", "statsd"} {
+		send, ok := sends[name]
+		if !ok {
+			continue
+		}
+		if logged {
+			send = loggedSend(name, send)
+		}
+		sinks = append(sinks, newAsyncSink(name, send))
+	}
+	return sinks, nil
+}
+
+// EnablePush sends the dashboard's snapshots to InfluxDB and/or StatsD.
+func (d *Dashboard) EnablePush(opts PushOptions) error {
+	sinks, err := pushSinks(opts, false)
+	if err != nil {
+		return err
+	}
+	d.sinks = append(d.sinks, sinks...)
+	return nil
+}
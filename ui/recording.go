@@ -0,0 +1,275 @@
+package ui
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// recordingVersion is bumped when the framing of a recording changes. The
+// snapshots inside carry their own snapshotVersion.
+const recordingVersion = 1
+
+const recordingFormat = "wtop-recording"
+
+// recordingHeader is the first line of a recording. The rest of the gzip
+// stream is one snapshot JSON document per line.
+type recordingHeader struct {
+	Format   string    `json:"format"`
+	Version  int       `json:"version"`
+	Hostname string    `json:"hostname"`
+	Started  time.Time `json:"started"`
+	Interval float64   `json:"intervalSeconds"`
+}
+
+type RecordOptions struct {
+	Interval time.Duration
+	Duration time.Duration
+	TopN     int
+}
+
+// recordingWriter appends snapshots to a recording. Every snapshot is flushed
+// through gzip so a recording cut short by a crash or kill is still readable
+// up to its last complete snapshot.
+type recordingWriter struct {
+	file *os.File
+	buf  *bufio.Writer
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+func createRecording(path string, header recordingHeader) (*recordingWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(f)
+	gz := gzip.NewWriter(buf)
+	w := &recordingWriter{file: f, buf: buf, gz: gz, enc: json.NewEncoder(gz)}
+	header.Format = recordingFormat
+	header.Version = recordingVersion
+	if err := w.enc.Encode(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *recordingWriter) write(snap *snapshot) error {
+	if err := w.enc.Encode(snap); err != nil {
+		return err
+	}
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+func (w *recordingWriter) Close() error {
+	err := w.gz.Close()
+	if ferr := w.buf.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readRecording loads every snapshot in a recording. A truncated tail is
+// tolerated so recordings from an interrupted `wtop record` still replay.
+func readRecording(r io.Reader) (*recordingHeader, []*snapshot, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a wtop recording: %w", err)
+	}
+	defer gz.Close()
+	br := bufio.NewReader(gz)
+
+	line, err := br.ReadBytes('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	var header recordingHeader
+	if err := json.Unmarshal(line, &header); err != nil || header.Format != recordingFormat {
+		return nil, nil, fmt.Errorf("not a wtop recording")
+	}
+	if header.Version > recordingVersion {
+		return nil, nil, fmt.Errorf("recording version %d is newer than supported version %d", header.Version, recordingVersion)
+	}
+
+	var frames []*snapshot
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			snap := &snapshot{}
+			if jerr := json.Unmarshal(line, snap); jerr != nil {
+				return nil, nil, fmt.Errorf("snapshot %d: %w", len(frames)+1, jerr)
+			}
+			frames = append(frames, snap)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(frames) == 0 {
+		return nil, nil, fmt.Errorf("recording contains no snapshots")
+	}
+	return &header, frames, nil
+}
+
+func loadRecording(path string) (*recordingHeader, []*snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return readRecording(bufio.NewReader(f))
+}
+
+// Record writes a snapshot to path every interval until the duration elapses
+// or the process is interrupted.
+func Record(path string, opts RecordOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = refreshInterval
+	}
+	if opts.TopN <= 0 {
+		opts.TopN = maxProcessEntries
+	}
+
+	warmUp(defaultWarmup)
+	first, err := collectSnapshot(opts.TopN)
+	if err != nil {
+		return err
+	}
+	w, err := createRecording(path, recordingHeader{
+		Hostname: first.Hostname,
+		Started:  first.Timestamp,
+		Interval: opts.Interval.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.write(first); err != nil {
+		w.Close()
+		return err
+	}
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+		case <-afterOrNever(opts.Duration):
+		}
+		close(stop)
+	}()
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	var runErr error
+	collectEvery(ticker, stop, opts.TopN, func(snap *snapshot, err error) bool {
+		if err != nil {
+			return true
+		}
+		runErr = w.write(snap)
+		return runErr == nil
+	})
+	if err := w.Close(); runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+func afterOrNever(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return time.After(d)
+}
+
+// liveRecording records the dashboard's snapshots from the command palette,
+// as `wtop record` would, until it is stopped or wtop exits.
+type liveRecording struct {
+	path   string
+	w      *recordingWriter
+	frames int
+	err    error
+}
+
+func (r *liveRecording) push(snap *snapshot) {
+	if r.err != nil || snap == nil {
+		return
+	}
+	if r.err = r.w.write(snap); r.err == nil {
+		r.frames++
+	}
+}
+
+func (r *liveRecording) footerStatus() string {
+	if r.err != nil {
+		return fmt.Sprintf("%srecord: %v[-]", critTag(), r.err)
+	}
+	return fmt.Sprintf("%sREC %s (%d)[-]", critTag(), filepath.Base(r.path), r.frames)
+}
+
+// startRecording starts recording to path, beginning with the snapshot on
+// screen.
+func (d *Dashboard) startRecording(path string) error {
+	if d.replay != nil {
+		return fmt.Errorf("cannot record while replaying")
+	}
+	if d.recording != nil {
+		return fmt.Errorf("already recording to %s", d.recording.path)
+	}
+	header := recordingHeader{Started: time.Now(), Interval: d.refreshInterval.Seconds()}
+	if d.lastSnapshot != nil {
+		header.Hostname = d.lastSnapshot.Hostname
+		header.Started = d.lastSnapshot.Timestamp
+	}
+	w, err := createRecording(path, header)
+	if err != nil {
+		return err
+	}
+	d.recording = &liveRecording{path: path, w: w}
+	d.recording.push(d.lastSnapshot)
+	d.sinks = append(d.sinks, d.recording)
+	return nil
+}
+
+// stopRecording closes the recording started from the palette, if any.
+func (d *Dashboard) stopRecording() error {
+	r := d.recording
+	if r == nil {
+		return nil
+	}
+	d.recording = nil
+	for i, sink := range d.sinks {
+		if sink == snapshotSink(r) {
+			d.sinks = append(d.sinks[:i], d.sinks[i+1:]...)
+			break
+		}
+	}
+	if err := r.w.Close(); r.err == nil {
+		r.err = err
+	}
+	if r.err != nil {
+		return r.err
+	}
+	d.setNotice(fmt.Sprintf("Recorded %d snapshots to %s", r.frames, r.path))
+	return nil
+}
@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+type RemoteOptions struct {
+	Addr     string
+	Token    string
+	TLS      bool
+	CAFile   string
+	Insecure bool
+}
+
+// remoteClient holds the connection to a `wtop agent`. The receive loop owns
+// reconnection; the UI only reads the status and sends actions.
+type remoteClient struct {
+	opts RemoteOptions
+	tls  *tls.Config
+
+	mu      sync.Mutex
+	fc      *frameConn
+	welcome welcomeMsg
+	state   string
+	nextID  uint64
+}
+
+func newRemoteClient(opts RemoteOptions) (*remoteClient, error) {
+	r := &remoteClient{opts: opts, state: "connecting"}
+	if opts.TLS || opts.CAFile != "" || opts.Insecure {
+		host, _, err := net.SplitHostPort(opts.Addr)
+		if err != nil {
+			return nil, err
+		}
+		r.tls = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12, InsecureSkipVerify: opts.Insecure}
+		if opts.CAFile != "" {
+			pem, err := os.ReadFile(opts.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("%s: no certificates found", opts.CAFile)
+			}
+			r.tls.RootCAs = pool
+		}
+	}
+	return r, nil
+}
+
+func (r *remoteClient) dial() (*frameConn, welcomeMsg, error) {
+	dialer := &net.Dialer{Timeout: handshakeTimeout, KeepAlive: 15 * time.Second}
+	var conn net.Conn
+	var err error
+	if r.tls != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.opts.Addr, r.tls)
+	} else {
+		conn, err = dialer.Dial("tcp", r.opts.Addr)
+	}
+	if err != nil {
+		return nil, welcomeMsg{}, err
+	}
+
+	fc := newFrameConn(conn)
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	var welcome welcomeMsg
+	err = fc.writeFrame(frameHello, helloMsg{Version: protocolVersion, Token: r.opts.Token}, false)
+	if err == nil {
+		err = fc.expectFrame(frameWelcome, &welcome)
+	}
+	if err != nil {
+		fc.Close()
+		return nil, welcomeMsg{}, err
+	}
+	conn.SetDeadline(time.Time{})
+	return fc, welcome, nil
+}
+
+func (r *remoteClient) setState(state string) {
+	r.mu.Lock()
+	r.state = state
+	r.mu.Unlock()
+}
+
+func (r *remoteClient) status() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fc != nil {
+		return fmt.Sprintf("%s%s %s[-]", goodTag(), glyphs.connected, r.opts.Addr)
+	}
+	return fmt.Sprintf("%s%s %s %s[-]", warnTag(), glyphs.disconnected, r.opts.Addr, r.state)
+}
+
+// connection reports whether the link is up and, when it is not, why.
+func (r *remoteClient) connection() (bool, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fc != nil, r.state
+}
+
+func (r *remoteClient) sendAction(pid int32, signal string) error {
+	r.mu.Lock()
+	fc, allowed := r.fc, r.welcome.Actions
+	r.nextID++
+	id := r.nextID
+	r.mu.Unlock()
+	if fc == nil {
+		return fmt.Errorf("not connected")
+	}
+	if !allowed {
+		return fmt.Errorf("the agent does not allow process actions")
+	}
+	return fc.writeFrame(frameAction, actionMsg{ID: id, PID: pid, Signal: signal}, false)
+}
+
+// Connect shows the snapshots streamed by a `wtop agent` in the dashboard.
+func Connect(opts RemoteOptions) error {
+	remote, err := newRemoteClient(opts)
+	if err != nil {
+		return err
+	}
+	d := NewDashboard()
+	d.remote = remote
+	return d.Run()
+}
+
+// remoteHandler receives what the connection loop reads from the agent.
+// first is true for the first snapshot after every (re)connect, when rates
+// cannot be computed yet.
+type remoteHandler struct {
+	snapshot func(snap *snapshot, first bool)
+	result   func(result resultMsg)
+	changed  func()
+}
+
+// run keeps a connection to the agent open until stop is closed,
+// reconnecting with exponential backoff.
+func (r *remoteClient) run(stop <-chan struct{}, h remoteHandler) {
+	delay := minReconnectDelay
+	for {
+		fc, welcome, err := r.dial()
+		if err == nil {
+			delay = minReconnectDelay
+			r.mu.Lock()
+			r.fc, r.welcome = fc, welcome
+			r.mu.Unlock()
+			h.changed()
+			err = r.receive(stop, fc, welcome, h)
+			r.mu.Lock()
+			r.fc = nil
+			r.mu.Unlock()
+			fc.Close()
+		}
+
+		r.setState(fmt.Sprintf("reconnecting in %s: %v", delay, err))
+		h.changed()
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+		r.setState("connecting")
+	}
+}
+
+// receive reads frames until the connection fails. A link that stays silent
+// for several intervals is treated as dead.
+func (r *remoteClient) receive(stop <-chan struct{}, fc *frameConn, welcome welcomeMsg, h remoteHandler) error {
+	idle := 3*time.Duration(welcome.Interval*float64(time.Second)) + handshakeTimeout
+	first := true
+	for {
+		fc.conn.SetReadDeadline(time.Now().Add(idle))
+		kind, payload, err := fc.readFrame()
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			return err
+		}
+		switch kind {
+		case frameSnapshot:
+			snap := &snapshot{}
+			if err := json.Unmarshal(payload, snap); err != nil {
+				return err
+			}
+			h.snapshot(snap, first)
+			first = false
+		case frameResult:
+			var result resultMsg
+			if err := json.Unmarshal(payload, &result); err == nil && h.result != nil {
+				h.result(result)
+			}
+		case frameError:
+			var msg errorMsg
+			json.Unmarshal(payload, &msg)
+			return fmt.Errorf("agent: %s", msg.Message)
+		}
+	}
+}
+
+func (r *remoteClient) interval() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return time.Duration(r.welcome.Interval * float64(time.Second))
+}
+
+func (d *Dashboard) remoteLoop() {
+	d.remote.run(d.stopCh, d.remoteEvents())
+}
+
+// remoteEvents returns the handler that shows what the agent sends in the
+// dashboard.
+func (d *Dashboard) remoteEvents() remoteHandler {
+	return remoteHandler{
+		snapshot: func(snap *snapshot, first bool) {
+			d.app.QueueUpdateDraw(func() {
+				if interval := d.remote.interval(); interval > 0 {
+					d.refreshInterval = interval
+				}
+				d.applySnapshot(snap, !first)
+			})
+		},
+		result: func(result resultMsg) {
+			if result.Error == "" {
+				return
+			}
+			d.app.QueueUpdateDraw(func() {
+				d.setNotice(fmt.Sprintf("%sagent: %s[-]", critTag(), result.Error))
+			})
+		},
+		changed: func() {
+			d.app.QueueUpdateDraw(func() {
+				d.updateFooter(d.lastSnapshot, d.lastRates)
+			})
+		},
+	}
+}
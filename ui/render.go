@@ -6,6 +6,9 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+
+	"github.com/SwarnenduG07/wtop/metrics"
+	"github.com/SwarnenduG07/wtop/types"
 )
 
 const (
@@ -42,33 +45,56 @@ type netRates struct {
 type Dashboard struct {
 	app *tview.Application
 
+	pages       *tview.Pages
 	root        *tview.Flex
+	tabBar      *tview.TextView
+	body        *tview.Flex
 	leftFlex    *tview.Flex
 	rightFlex   *tview.Flex
 	mainFlex    *tview.Flex
-	cpuFlex     *tview.Flex
 	memDiskFlex *tview.Flex
-	gpuFlex     *tview.Flex
+	memoryRow   tview.Primitive
 	header      *tview.TextView
 	lastRates   netRates
+	lastPower   cpuPower
 
 	cpuView      *tview.TextView
 	memoryView   *tview.TextView
 	diskView     *tview.TextView
 	gpuView      *tview.TextView
+	sensorView   *tview.TextView
 	processTable *tview.Table
+	tableProcs   []*types.ProcessInfo
+	filterInput  *tview.InputField
 	footer       *tview.TextView
 
+	networkFlex *tview.Flex
+	networkView *tview.TextView
+	connTable   *tview.Table
+	conns       connectionList
+	diskIOView  *tview.TextView
+	gpuFlex     *tview.Flex
+	gpuHistView *tview.TextView
+
+	keymap    keymap
+	helpView  *tview.TextView
+	helpShown bool
+
 	refreshInterval time.Duration
 	ticker          *time.Ticker
 	stopCh          chan struct{}
 
 	sortMode     SortMode
+	filter       string
 	lastSnapshot *snapshot
+	memDetail    bool
+	showPSS      bool
 
 	prevNetSent  uint64
 	prevNetRecv  uint64
 	prevSnapshot time.Time
+	prevRAPL     map[string]*metrics.RAPLZone
+	prevRAPLTime time.Time
 
 	cpuHistory   *sparkHistory
 	memHistory   *sparkHistory
@@ -76,18 +102,65 @@ type Dashboard struct {
 	diskHistory  *sparkHistory
 	netUpHistory *sparkHistory
 	netDnHistory *sparkHistory
-	gpuHistory   map[int]*sparkHistory
+	gpuHistory   map[int]*gpuSeries
+
+	psiCPUHistory *sparkHistory
+	psiMemHistory *sparkHistory
+	psiIOHistory  *sparkHistory
 
+	sensorHistory map[string]*sparkHistory
+	batteryTrend  batteryTrend
+
+	prevDevices   *snapshot
+	ifRates       map[string]netRates
+	diskRates     map[string]diskRates
+	ifHistory     map[string]*ioHistory
+	diskIOHistory map[string]*ioHistory
+
+	historySize     int
 	lastLayoutWidth int
+
+	tab        tab
+	layout     PanelLayout
+	layoutName string
+	focused    string // panel with the focus, "processes" for the table
+	zoomed     string // panel shown alone, if any
+	gpuHeight  int    // fixed height of the GPU panel when there is no GPU
+	relayout   bool   // panels need redrawing at their new size
+
+	sinks  []snapshotSink
+	replay *replayer
+	remote *remoteClient
+	flight *flightRecorder
+	// remoteShared is set when the fleet view owns the connection and
+	// forwards its frames, so Run does not dial the agent again.
+	remoteShared bool
+
+	recording    *liveRecording
+	paletteInput *tview.InputField
+	palette      []paletteSuggestion // entries of the open completion list
+	// paletteNavigated is set once the user moves through the list, so that
+	// Enter takes the highlighted entry instead of the typed text.
+	paletteNavigated bool
+
+	pendingAction *processAction
+	notice        string
+	noticeAt      time.Time
+
+	history      *historyStore
+	historyRange int
+	historyErr   error
 }
 
 func NewDashboard() *Dashboard {
 	app := tview.NewApplication()
 	dash := &Dashboard{
 		app:             app,
-		refreshInterval: refreshInterval,
+		refreshInterval: activeConfig.Refresh,
 		stopCh:          make(chan struct{}),
-		sortMode:        SortByCPU,
+		sortMode:        activeConfig.sortMode(),
+		filter:          activeConfig.Filter,
+		historySize:     activeConfig.History,
 	}
 
 	dash.header = dash.newSection(" SUMMARY ")
@@ -105,101 +178,173 @@ func NewDashboard() *Dashboard {
 	dash.gpuView = dash.newSection(" GPU ")
 	dash.gpuView.SetWrap(false)
 
+	dash.sensorView = dash.newSection(" SENSORS ")
+	dash.sensorView.SetWrap(false)
+
 	dash.processTable = tview.NewTable().SetBorders(false)
-	dash.processTable.SetBackgroundColor(tcell.ColorBlack)
+	dash.processTable.SetBackgroundColor(activeTheme.Background)
 	dash.processTable.SetTitle(" Processes ")
-	dash.processTable.SetTitleColor(tcell.ColorLightCyan)
+	dash.processTable.SetTitleColor(activeTheme.Accent)
 	dash.processTable.SetBorder(true)
-	dash.processTable.SetBorderColor(tcell.ColorDarkSlateGray)
+	dash.processTable.SetBorderColor(activeTheme.Border)
 	dash.processTable.SetSelectable(true, false)
 	dash.processTable.SetFixed(1, 0)
-	dash.processTable.SetSelectedStyle(tcell.StyleDefault.
-		Foreground(tcell.ColorBlack).
-		Background(tcell.ColorLightCyan).
-		Bold(true))
+	dash.processTable.SetSelectedStyle(activeTheme.selectedStyle())
+	dash.processTable.SetSelectionChangedFunc(func(int, int) {
+		// The memory panel shows the cgroup pressure of the selected process.
+		if dash.lastSnapshot != nil {
+			dash.updateMemory(dash.lastSnapshot)
+		}
+	})
+
+	dash.networkView = dash.newSection(" INTERFACES ")
+	dash.connTable = tview.NewTable().SetBorders(false)
+	dash.connTable.SetBackgroundColor(activeTheme.Background)
+	dash.connTable.SetTitle(" Connections ")
+	dash.connTable.SetTitleColor(activeTheme.Accent)
+	dash.connTable.SetBorder(true)
+	dash.connTable.SetBorderColor(activeTheme.Border)
+	dash.connTable.SetSelectable(true, false)
+	dash.connTable.SetFixed(1, 0)
+	dash.connTable.SetSelectedStyle(activeTheme.selectedStyle())
+	dash.networkFlex = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(dash.networkView, 0, 1, false).
+		AddItem(dash.connTable, 0, 1, false)
+
+	dash.diskIOView = dash.newSection(" DISK I/O ")
+	dash.gpuHistView = dash.newSection(" GPU HISTORY ")
+	dash.gpuFlex = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(dash.gpuHistView, 0, 1, false).
+		AddItem(dash.gpuView, 0, 1, false)
 
 	dash.footer = tview.NewTextView().
 		SetDynamicColors(true).
 		SetRegions(false).
 		SetWrap(false)
-	dash.footer.SetBackgroundColor(tcell.ColorDimGray)
+	dash.footer.SetBackgroundColor(activeTheme.StatusBar)
 
-	dash.cpuFlex = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(dash.cpuView, 0, 1, false)
+	dash.filterInput = tview.NewInputField().
+		SetLabel("Filter: ").
+		SetText(dash.filter).
+		SetFieldBackgroundColor(activeTheme.Background).
+		SetLabelColor(activeTheme.Accent)
+	dash.filterInput.SetBackgroundColor(activeTheme.StatusBar)
+	dash.filterInput.SetChangedFunc(dash.setFilter)
+	dash.filterInput.SetDoneFunc(dash.finishFilter)
 
-	dash.memDiskFlex = tview.NewFlex().SetDirection(tview.FlexColumn).
-		AddItem(dash.memoryView, 0, 1, false).
-		AddItem(dash.diskView, 0, 1, false)
+	dash.paletteInput = dash.newPaletteInput()
 
-	dash.gpuFlex = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(dash.gpuView, 0, 1, false)
-
-	dash.leftFlex = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(dash.cpuFlex, 0, 1, false).
-		AddItem(dash.memDiskFlex, 0, 1, false).
-		AddItem(dash.gpuFlex, 0, 2, false)
-
-	dash.rightFlex = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(dash.processTable, 0, 1, true)
-
-	dash.mainFlex = tview.NewFlex().SetDirection(tview.FlexColumn).
-		AddItem(dash.leftFlex, 0, 1, false).
-		AddItem(dash.rightFlex, 0, 1, false)
+	dash.tabBar = tview.NewTextView().SetDynamicColors(true).SetWrap(false)
+	dash.tabBar.SetBackgroundColor(activeTheme.StatusBar)
+	dash.updateTabBar()
 
+	dash.body = tview.NewFlex().SetDirection(tview.FlexRow)
 	dash.root = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(dash.tabBar, 1, 0, false).
 		AddItem(dash.header, 3, 0, false).
-		AddItem(dash.mainFlex, 0, 1, false).
+		AddItem(dash.body, 0, 1, false).
+		AddItem(dash.filterInput, 0, 0, false).
+		AddItem(dash.paletteInput, 0, 0, false).
 		AddItem(dash.footer, 2, 0, false)
 
-	dash.root.SetBackgroundColor(tcell.ColorBlack)
-	dash.header.SetBackgroundColor(tcell.ColorBlack)
-	dash.cpuView.SetBackgroundColor(tcell.ColorBlack)
-	dash.memoryView.SetBackgroundColor(tcell.ColorBlack)
-	dash.diskView.SetBackgroundColor(tcell.ColorBlack)
-	dash.gpuView.SetBackgroundColor(tcell.ColorBlack)
-
-	dash.cpuHistory = newSparkHistory(historySize)
-	dash.memHistory = newSparkHistory(historySize)
-	dash.swapHistory = newSparkHistory(historySize)
-	dash.diskHistory = newSparkHistory(historySize)
-	dash.netUpHistory = newSparkHistory(historySize)
-	dash.netDnHistory = newSparkHistory(historySize)
-	dash.gpuHistory = make(map[int]*sparkHistory)
-
-	dash.app.SetRoot(dash.root, true)
-	dash.app.EnableMouse(true)
+	dash.root.SetBackgroundColor(activeTheme.Background)
+	dash.header.SetBackgroundColor(activeTheme.Background)
+	dash.cpuView.SetBackgroundColor(activeTheme.Background)
+	dash.memoryView.SetBackgroundColor(activeTheme.Background)
+	dash.diskView.SetBackgroundColor(activeTheme.Background)
+	dash.gpuView.SetBackgroundColor(activeTheme.Background)
+	dash.sensorView.SetBackgroundColor(activeTheme.Background)
+
+	dash.cpuHistory = newSparkHistory(dash.historySize)
+	dash.memHistory = newSparkHistory(dash.historySize)
+	dash.swapHistory = newSparkHistory(dash.historySize)
+	dash.diskHistory = newSparkHistory(dash.historySize)
+	dash.netUpHistory = newSparkHistory(dash.historySize)
+	dash.netDnHistory = newSparkHistory(dash.historySize)
+	dash.gpuHistory = make(map[int]*gpuSeries)
+	dash.psiCPUHistory = newSparkHistory(dash.historySize)
+	dash.psiMemHistory = newSparkHistory(dash.historySize)
+	dash.psiIOHistory = newSparkHistory(dash.historySize)
+	dash.sensorHistory = make(map[string]*sparkHistory)
+	dash.ifHistory = make(map[string]*ioHistory)
+	dash.diskIOHistory = make(map[string]*ioHistory)
+
+	dash.keymap, _ = buildKeymap(activeConfig.Keys)
+	dash.pages = tview.NewPages().
+		AddPage("main", dash.root, true, true).
+		AddPage("help", dash.newHelpPage(), true, false)
+	dash.app.SetRoot(dash.pages, true)
+	dash.focused = "processes"
+	dash.useLayout(activeConfig.Layout)
+	dash.app.EnableMouse(activeConfig.Mouse)
 	dash.bindKeys()
 	dash.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
 		width, _ := screen.Size()
 		dash.reflowLayout(width)
 		return false
 	})
+	dash.app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		if dash.relayout {
+			dash.relayout = false
+			go dash.app.QueueUpdateDraw(dash.rerender)
+		}
+	})
 
 	return dash
 }
 
 func (d *Dashboard) Run() error {
+	if d.replay != nil || d.remote != nil {
+		if d.replay != nil {
+			d.showReplayFrame(0)
+			go d.replayLoop()
+		} else {
+			if d.lastSnapshot == nil {
+				d.header.SetText(fmt.Sprintf("%sconnecting to %s...[-]", warnTag(), d.remote.opts.Addr))
+				d.updateFooter(nil, netRates{})
+			}
+			if !d.remoteShared {
+				go d.remoteLoop()
+			}
+		}
+		errRun := d.app.Run()
+		d.stop()
+		return errRun
+	}
+
 	initial, err := collectSnapshot(maxProcessEntries)
 	if err == nil {
 		d.lastSnapshot = initial
 		d.applySnapshot(initial, false)
 	} else {
-		d.header.SetText(fmt.Sprintf("[red]failed to gather metrics: %v[-]", err))
+		d.header.SetText(fmt.Sprintf("%sfailed to gather metrics: %v[-]", critTag(), err))
 	}
 
 	d.ticker = time.NewTicker(d.refreshInterval)
 	go d.updateLoop()
 
-	d.app.SetFocus(d.processTable)
 	errRun := d.app.Run()
 	d.stop()
+	if d.history != nil {
+		d.history.writes.Wait()
+		if err := d.history.save(); err != nil && errRun == nil {
+			errRun = err
+		}
+	}
 	return errRun
 }
 
+// localSource reports whether snapshots describe this machine, so per-process
+// details can be read from /proc on demand.
+func (d *Dashboard) localSource() bool {
+	return d.replay == nil && d.remote == nil
+}
+
 func (d *Dashboard) stop() {
 	if d.ticker != nil {
 		d.ticker.Stop()
 	}
+	d.stopRecording()
 	select {
 	case <-d.stopCh:
 
@@ -209,21 +354,32 @@ func (d *Dashboard) stop() {
 }
 
 func (d *Dashboard) updateLoop() {
+	collectEvery(d.ticker, d.stopCh, maxProcessEntries, func(snap *snapshot, err error) bool {
+		if err != nil {
+			d.app.QueueUpdateDraw(func() {
+				d.footer.SetText(fmt.Sprintf("%smetrics error: %v[-]", critTag(), err))
+			})
+			return true
+		}
+		d.app.QueueUpdateDraw(func() {
+			d.applySnapshot(snap, true)
+		})
+		return true
+	})
+}
+
+// collectEvery collects a snapshot on every tick and hands it to fn until
+// stop is closed or fn returns false.
+func collectEvery(ticker *time.Ticker, stop <-chan struct{}, limit int, fn func(*snapshot, error) bool) {
 	for {
 		select {
-		case <-d.stopCh:
+		case <-stop:
 			return
-		case <-d.ticker.C:
-			snap, err := collectSnapshot(maxProcessEntries)
-			if err != nil {
-				d.app.QueueUpdateDraw(func() {
-					d.footer.SetText(fmt.Sprintf("[red]metrics error: %v[-]", err))
-				})
-				continue
+		case <-ticker.C:
+			snap, err := collectSnapshot(limit)
+			if !fn(snap, err) {
+				return
 			}
-			d.app.QueueUpdateDraw(func() {
-				d.applySnapshot(snap, true)
-			})
 		}
 	}
 }
@@ -232,16 +388,29 @@ func (d *Dashboard) applySnapshot(snap *snapshot, fromLoop bool) {
 	if snap == nil {
 		return
 	}
+	if d.history != nil {
+		d.history.record(d.lastSnapshot, snap)
+		d.saveHistory(snap.Timestamp)
+	}
 	d.lastSnapshot = snap
 	rates := d.computeNetworkRates(snap, fromLoop)
 	d.lastRates = rates
+	d.lastPower = d.computeCPUPower(snap)
+	d.computeDeviceRates(snap, fromLoop)
 	d.recordHistory(snap, rates)
 	d.updateHeader(snap, rates)
+	// The process table goes first: the memory panel shows the cgroup
+	// pressure of the process selected in it.
+	d.updateProcessTable(snap)
 	d.updateCPU(snap)
 	d.updateMemory(snap)
 	d.updateGPU(snap)
-	d.updateProcessTable(snap)
+	d.updateSensors(snap)
+	d.updateTab(snap)
 	d.updateFooter(snap, rates)
+	for _, sink := range d.sinks {
+		sink.push(snap)
+	}
 }
 
 func (d *Dashboard) recordHistory(snap *snapshot, rates netRates) {
@@ -277,10 +446,57 @@ func (d *Dashboard) recordHistory(snap *snapshot, rates netRates) {
 		for _, gpu := range snap.GPUInfos {
 			hist := d.gpuHistory[gpu.Index]
 			if hist == nil {
-				hist = newSparkHistory(historySize)
+				hist = newGPUSeries(d.historySize)
 				d.gpuHistory[gpu.Index] = hist
 			}
-			hist.Push(gpu.Utilization)
+			hist.push(gpu)
+		}
+	}
+	for name, rates := range d.ifRates {
+		hist := d.ioHistoryFor(d.ifHistory, name)
+		hist.in.Push(rates.Down)
+		hist.out.Push(rates.Up)
+	}
+	for name, rates := range d.diskRates {
+		hist := d.ioHistoryFor(d.diskIOHistory, name)
+		hist.in.Push(rates.Read)
+		hist.out.Push(rates.Write)
+	}
+	// Interfaces and disks come and go with containers and hotplug; forget
+	// the ones that are gone.
+	present := map[string]bool{}
+	for _, c := range snap.Interfaces {
+		present[c.Name] = true
+	}
+	pruneIOHistory(d.ifHistory, present)
+	present = map[string]bool{}
+	for _, c := range snap.DiskIO {
+		present[c.Name] = true
+	}
+	pruneIOHistory(d.diskIOHistory, present)
+	if p := snap.Pressure; p != nil {
+		if p.CPU != nil {
+			d.psiCPUHistory.Push(p.CPU.Some.Avg10)
+		}
+		if p.Memory != nil {
+			d.psiMemHistory.Push(p.Memory.Some.Avg10)
+		}
+		if p.IO != nil {
+			d.psiIOHistory.Push(p.IO.Some.Avg10)
+		}
+	}
+	if snap.Power != nil {
+		d.batteryTrend.Push(snap.Timestamp, snap.Power.Battery())
+	}
+	for _, chip := range snap.Sensors {
+		for _, r := range chip.Readings {
+			key := sensorHistoryKey(chip, r)
+			hist := d.sensorHistory[key]
+			if hist == nil {
+				hist = newSparkHistory(d.historySize)
+				d.sensorHistory[key] = hist
+			}
+			hist.Push(r.Value)
 		}
 	}
 }
@@ -301,14 +517,8 @@ func (d *Dashboard) computeNetworkRates(snap *snapshot, fromLoop bool) netRates
 		return netRates{Valid: false}
 	}
 
-	up := 0.0
-	if snap.NetBytesSent >= d.prevNetSent {
-		up = float64(snap.NetBytesSent-d.prevNetSent) / elapsed
-	}
-	down := 0.0
-	if snap.NetBytesRecv >= d.prevNetRecv {
-		down = float64(snap.NetBytesRecv-d.prevNetRecv) / elapsed
-	}
+	up := counterRate(d.prevNetSent, snap.NetBytesSent, elapsed)
+	down := counterRate(d.prevNetRecv, snap.NetBytesRecv, elapsed)
 
 	d.prevNetSent = snap.NetBytesSent
 	d.prevNetRecv = snap.NetBytesRecv
@@ -317,6 +527,15 @@ func (d *Dashboard) computeNetworkRates(snap *snapshot, fromLoop bool) netRates
 	return netRates{Up: up, Down: down, Valid: true}
 }
 
+// counterRate returns the per-second rate of a monotonic counter, treating a
+// counter that went backwards (interface reset) as idle.
+func counterRate(prev, cur uint64, elapsed float64) float64 {
+	if cur < prev || elapsed <= 0 {
+		return 0
+	}
+	return float64(cur-prev) / elapsed
+}
+
 func (d *Dashboard) cycleSortMode() {
 	switch d.sortMode {
 	case SortByCPU:
@@ -332,40 +551,37 @@ func (d *Dashboard) cycleSortMode() {
 	}
 }
 
-func (d *Dashboard) newSection(title string) *tview.TextView {
-	tv := tview.NewTextView().
-		SetDynamicColors(true).
-		SetWrap(false)
-	tv.SetTitle(title)
-	tv.SetBorder(true)
-	tv.SetBorderColor(tcell.ColorDarkSlateGray)
-	tv.SetTitleColor(tcell.ColorLightCyan)
-	tv.SetBackgroundColor(tcell.ColorBlack)
-	return tv
+func (d *Dashboard) toggleProcessMemoryDetail() {
+	d.showPSS = !d.showPSS
+	if d.lastSnapshot != nil {
+		d.updateProcessTable(d.lastSnapshot)
+	}
 }
 
-func (d *Dashboard) reflowLayout(width int) {
-	if width <= 0 {
+func (d *Dashboard) toggleMemoryDetail() {
+	if d.memoryRow == nil {
 		return
 	}
-	if width == d.lastLayoutWidth {
-		return
-	}
-	d.lastLayoutWidth = width
-
-	if width < 100 {
-		d.mainFlex.SetDirection(tview.FlexRow)
-		d.mainFlex.ResizeItem(d.leftFlex, 0, 2)
-		d.mainFlex.ResizeItem(d.rightFlex, 0, 3)
+	d.memDetail = !d.memDetail
+	d.buildLayout()
+	if d.memDetail {
+		d.memoryView.SetTitle(" MEMORY" + glyphs.sep + "detail ")
 	} else {
-		d.mainFlex.SetDirection(tview.FlexColumn)
-		d.mainFlex.ResizeItem(d.leftFlex, 0, 1)
-		d.mainFlex.ResizeItem(d.rightFlex, 0, 1)
+		d.memoryView.SetTitle(" MEMORY ")
 	}
-
-	if width < 80 {
-		d.footer.SetWrap(true)
-	} else {
-		d.footer.SetWrap(false)
+	if d.lastSnapshot != nil {
+		d.updateMemory(d.lastSnapshot)
 	}
 }
+
+func (d *Dashboard) newSection(title string) *tview.TextView {
+	tv := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWrap(false)
+	tv.SetTitle(title)
+	tv.SetBorder(true)
+	tv.SetBorderColor(activeTheme.Border)
+	tv.SetTitleColor(activeTheme.Accent)
+	tv.SetBackgroundColor(activeTheme.Background)
+	return tv
+}
@@ -0,0 +1,255 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	minReplayDelay = 20 * time.Millisecond
+	maxReplayDelay = 10 * time.Second
+	replaySeekStep = 30 * time.Second
+)
+
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4, 8, 16, 32, 64}
+
+// replayer owns the playback position of a recording. The playback goroutine
+// and the key handlers both move the position, so it is guarded by mu; the
+// frames themselves are immutable.
+type replayer struct {
+	frames []*snapshot
+	wake   chan struct{}
+
+	mu     sync.Mutex
+	pos    int
+	paused bool
+	speed  int // index into replaySpeeds
+
+	// shown is the frame currently on screen; only touched on the UI goroutine.
+	shown int
+}
+
+func newReplayer(frames []*snapshot) *replayer {
+	return &replayer{frames: frames, wake: make(chan struct{}, 1), speed: 2, shown: -1}
+}
+
+func (r *replayer) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextDelay returns how long to wait before the next frame at the current
+// speed, or false while paused or at the end of the recording.
+func (r *replayer) nextDelay() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused || r.pos >= len(r.frames)-1 {
+		return 0, false
+	}
+	gap := r.frames[r.pos+1].Timestamp.Sub(r.frames[r.pos].Timestamp)
+	delay := time.Duration(float64(gap) / replaySpeeds[r.speed])
+	if delay < minReplayDelay {
+		delay = minReplayDelay
+	}
+	if delay > maxReplayDelay {
+		delay = maxReplayDelay
+	}
+	return delay, true
+}
+
+func (r *replayer) advance() (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused || r.pos >= len(r.frames)-1 {
+		return r.pos, false
+	}
+	r.pos++
+	return r.pos, true
+}
+
+func (r *replayer) update(fn func()) int {
+	r.mu.Lock()
+	fn()
+	if r.pos < 0 {
+		r.pos = 0
+	}
+	if r.pos > len(r.frames)-1 {
+		r.pos = len(r.frames) - 1
+	}
+	pos := r.pos
+	r.mu.Unlock()
+	r.notify()
+	return pos
+}
+
+func (r *replayer) position() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pos
+}
+
+// seekTime returns the index of the last frame at or before t.
+func (r *replayer) seekTime(t time.Time) int {
+	lo, hi := 0, len(r.frames)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if r.frames[mid].Timestamp.After(t) {
+			hi = mid - 1
+		} else {
+			lo = mid
+		}
+	}
+	return lo
+}
+
+func (r *replayer) status() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := glyphs.playing
+	if r.paused {
+		state = glyphs.paused
+	} else if r.pos >= len(r.frames)-1 {
+		state = glyphs.stopped
+	}
+	at := r.frames[r.pos].Timestamp
+	elapsed := at.Sub(r.frames[0].Timestamp).Round(time.Second)
+	total := r.frames[len(r.frames)-1].Timestamp.Sub(r.frames[0].Timestamp).Round(time.Second)
+	return fmt.Sprintf("%sReplay %s %s %s/%s (%d/%d) %gx[-]", warnTag(),
+		state, at.Local().Format("2006-01-02 15:04:05"), elapsed, total,
+		r.pos+1, len(r.frames), replaySpeeds[r.speed])
+}
+
+// Replay drives the dashboard from a recording written by Record instead of
+// collecting live metrics.
+func Replay(path string) error {
+	_, frames, err := loadRecording(path)
+	if err != nil {
+		return err
+	}
+	d := NewDashboard()
+	d.replay = newReplayer(frames)
+	return d.Run()
+}
+
+func (d *Dashboard) replayLoop() {
+	r := d.replay
+	for {
+		var tick <-chan time.Time
+		var timer *time.Timer
+		if delay, ok := r.nextDelay(); ok {
+			timer = time.NewTimer(delay)
+			tick = timer.C
+		}
+		select {
+		case <-d.stopCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-r.wake:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-tick:
+			if _, ok := r.advance(); ok {
+				// Read the position again on the UI goroutine in case a key
+				// press moved it while this update was queued.
+				d.app.QueueUpdateDraw(func() {
+					d.showReplayFrame(r.position())
+				})
+			}
+		}
+	}
+}
+
+// showReplayFrame puts frame i on screen. Moving one frame forward is applied
+// like a live update; any other jump rebuilds the sparkline history from the
+// frames leading up to i so the graphs match what was seen at the time.
+func (d *Dashboard) showReplayFrame(i int) {
+	r := d.replay
+	if i == r.shown {
+		d.updateFooter(d.lastSnapshot, d.lastRates)
+		return
+	}
+	if i != r.shown+1 {
+		d.resetHistory()
+		start := i - d.historySize
+		if start < 0 {
+			start = 0
+		}
+		for j := start; j < i; j++ {
+			snap := r.frames[j]
+			d.lastRates = d.computeNetworkRates(snap, j > start)
+			d.lastPower = d.computeCPUPower(snap)
+			d.computeDeviceRates(snap, j > start)
+			d.recordHistory(snap, d.lastRates)
+		}
+	}
+	d.applySnapshot(r.frames[i], i > 0)
+	r.shown = i
+}
+
+func (d *Dashboard) resetHistory() {
+	for _, hist := range []*sparkHistory{
+		d.cpuHistory, d.memHistory, d.swapHistory, d.diskHistory,
+		d.netUpHistory, d.netDnHistory, d.psiCPUHistory, d.psiMemHistory, d.psiIOHistory,
+	} {
+		*hist = *newSparkHistory(d.historySize)
+	}
+	d.gpuHistory = make(map[int]*gpuSeries)
+	d.sensorHistory = make(map[string]*sparkHistory)
+	d.ifHistory = make(map[string]*ioHistory)
+	d.diskIOHistory = make(map[string]*ioHistory)
+	d.prevDevices = nil
+	d.batteryTrend = batteryTrend{}
+	d.prevSnapshot = time.Time{}
+	d.prevRAPL = nil
+	d.prevRAPLTime = time.Time{}
+	d.lastRates = netRates{}
+}
+
+// The playback controls, bound in the keymap.
+
+func (d *Dashboard) replayTogglePause() {
+	r := d.replay
+	d.showReplayFrame(r.update(func() { r.paused = !r.paused }))
+}
+
+// replayStep pauses and moves step frames.
+func (d *Dashboard) replayStep(step int) {
+	r := d.replay
+	d.showReplayFrame(r.update(func() { r.paused = true; r.pos += step }))
+}
+
+// replaySeek moves by step in recorded time, at least one frame forward.
+func (d *Dashboard) replaySeek(step time.Duration) {
+	r := d.replay
+	d.showReplayFrame(r.update(func() {
+		cur := r.pos
+		r.pos = r.seekTime(r.frames[cur].Timestamp.Add(step))
+		if step > 0 && r.pos == cur {
+			r.pos++
+		}
+	}))
+}
+
+func (d *Dashboard) replaySpeed(step int) {
+	r := d.replay
+	d.showReplayFrame(r.update(func() {
+		r.speed = clampInt(r.speed+step, 0, len(replaySpeeds)-1)
+	}))
+}
+
+// replayJump goes to the first frame, or the last when end is set.
+func (d *Dashboard) replayJump(end bool) {
+	r := d.replay
+	d.showReplayFrame(r.update(func() {
+		r.pos = 0
+		if end {
+			r.pos = len(r.frames) - 1
+		}
+	}))
+}
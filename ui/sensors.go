@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/SwarnenduG07/wtop/metrics"
+)
+
+func (d *Dashboard) updateSensors(snap *snapshot) {
+	if snap == nil || len(snap.Sensors) == 0 {
+		d.sensorView.SetText(mutedTag() + "No hardware sensors detected[-]")
+		return
+	}
+
+	_, _, width, _ := d.sensorView.GetInnerRect()
+	if width <= 0 {
+		width = 80
+	}
+	labelWidth := clampInt(width/4, 8, 20)
+	sparkWidth := clampInt(width-labelWidth-36, 8, 40)
+
+	var lines []string
+	for _, chip := range snap.Sensors {
+		lines = append(lines, fmt.Sprintf("%s%s%s %s(%s)[-]",
+			accentTag(), chip.Name, resetTag(), mutedTag(), chip.ID))
+
+		for _, r := range chip.Readings {
+			line := fmt.Sprintf("  %s %s%s%10s%s",
+				padLabel(r.Label, labelWidth), sensorMarker(r),
+				colorTag(sensorColor(r)), formatSensorValue(r.Kind, r.Value), resetTag())
+			if limits := formatSensorLimits(r); limits != "" {
+				line += " " + limits
+			}
+			key := sensorHistoryKey(chip, r)
+			if hist := d.sensorHistory[key]; hist != nil && width >= 60 {
+				line += "  " + renderSparkline(d.sparkSeries("sensor."+key, hist), sparkWidth)
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	d.sensorView.SetText(strings.Join(lines, "\n"))
+}
+
+func sensorHistoryKey(chip *metrics.SensorChip, r *metrics.SensorReading) string {
+	return chip.ID + "/" + r.Key
+}
+
+func sensorColor(r *metrics.SensorReading) tcell.Color {
+	switch {
+	case r.Critical > 0 && r.Value >= r.Critical:
+		return activeTheme.Critical
+	case r.High > 0 && r.Value >= r.High:
+		return activeTheme.Warning
+	case r.Kind == metrics.SensorFan && r.Low > 0 && r.Value < r.Low:
+		return activeTheme.Critical
+	case r.Kind == metrics.SensorTemperature && r.High <= 0 && r.Critical <= 0:
+		return usageColor(r.Value)
+	case r.Kind == metrics.SensorTemperature:
+		return activeTheme.Good
+	default:
+		return activeTheme.Text
+	}
+}
+
+// sensorMarker is the text form of the warning and critical colors of
+// sensorColor.
+func sensorMarker(r *metrics.SensorReading) string {
+	critical := (r.Critical > 0 && r.Value >= r.Critical) ||
+		(r.Kind == metrics.SensorFan && r.Low > 0 && r.Value < r.Low)
+	warning := critical || (r.High > 0 && r.Value >= r.High)
+	if r.Kind == metrics.SensorTemperature && r.High <= 0 && r.Critical <= 0 {
+		return levelMarker(r.Value)
+	}
+	return markLevel(warning, critical)
+}
+
+func formatSensorValue(kind metrics.SensorKind, value float64) string {
+	switch kind {
+	case metrics.SensorFan:
+		return fmt.Sprintf("%.0f %s", value, kind.Unit())
+	case metrics.SensorTemperature:
+		return formatTemperature(value, 1)
+	default:
+		return fmt.Sprintf("%.2f %s", value, kind.Unit())
+	}
+}
+
+func formatSensorLimits(r *metrics.SensorReading) string {
+	limit := func(v float64) float64 {
+		if r.Kind == metrics.SensorTemperature {
+			return convertTemperature(v)
+		}
+		return v
+	}
+	var parts []string
+	if r.Kind == metrics.SensorFan && r.Low > 0 {
+		parts = append(parts, fmt.Sprintf("min %.0f", r.Low))
+	}
+	if r.High > 0 {
+		parts = append(parts, fmt.Sprintf("high %.0f", limit(r.High)))
+	}
+	if r.Critical > 0 {
+		parts = append(parts, fmt.Sprintf("crit %.0f", limit(r.Critical)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return mutedTag() + "(" + strings.Join(parts, ", ") + ")[-]"
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// snapshotSink receives every snapshot collected by the dashboard or by the
+// headless serve mode.
+type snapshotSink interface {
+	push(snap *snapshot)
+}
+
+// maxQueuedSnapshots bounds how many snapshots an asyncSink holds while its
+// collector is slow or unreachable; beyond that the oldest are dropped.
+const maxQueuedSnapshots = 64
+
+// asyncSink runs a slow exporter on its own goroutine. Snapshots arriving
+// while a send is in flight are queued and handed over together with the
+// next send, so a batching exporter such as InfluxDB writes them in one
+// request, and a stalled collector never blocks collection or the UI.
+type asyncSink struct {
+	name string
+	send func([]*snapshot) error
+	wake chan struct{}
+
+	mu      sync.Mutex
+	queue   []*snapshot
+	lastErr error
+}
+
+func newAsyncSink(name string, send func([]*snapshot) error) *asyncSink {
+	s := &asyncSink{name: name, send: send, wake: make(chan struct{}, 1)}
+	go s.run()
+	return s
+}
+
+func (s *asyncSink) push(snap *snapshot) {
+	if snap == nil {
+		return
+	}
+	s.mu.Lock()
+	if len(s.queue) >= maxQueuedSnapshots {
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, snap)
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *asyncSink) run() {
+	for range s.wake {
+		s.mu.Lock()
+		batch := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+		if len(batch) == 0 {
+			continue
+		}
+
+		err := s.send(batch)
+		s.mu.Lock()
+		s.lastErr = err
+		s.mu.Unlock()
+	}
+}
+
+// eachSnapshot adapts an exporter that sends one snapshot at a time to
+// asyncSink, returning the last error.
+func eachSnapshot(send func(*snapshot) error) func([]*snapshot) error {
+	return func(snaps []*snapshot) error {
+		var err error
+		for _, snap := range snaps {
+			err = send(snap)
+		}
+		return err
+	}
+}
+
+func (s *asyncSink) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", s.name, s.lastErr)
+}
+
+// EnableOTLP pushes the dashboard's snapshots to an OpenTelemetry collector.
+func (d *Dashboard) EnableOTLP(opts OTLPOptions) error {
+	exp, err := newOTLPExporter(opts)
+	if err != nil {
+		return err
+	}
+	d.sinks = append(d.sinks, newAsyncSink("otlp", eachSnapshot(exp.export)))
+	return nil
+}
+
+type ServeOptions struct {
+	Interval       time.Duration
+	PrometheusAddr string
+	PrometheusTopN int
+	OTLP           *OTLPOptions
+	Push           *PushOptions
+	Flight         *FlightOptions
+	HistoryFile    string
+}
+
+// collectUntilInterrupted warms up, then hands a snapshot to push every
+// interval until the process receives SIGINT or SIGTERM.
+func collectUntilInterrupted(interval time.Duration, push func(*snapshot)) {
+	warmUp(defaultWarmup)
+	if snap, err := collectSnapshot(maxProcessEntries); err == nil {
+		push(snap)
+	}
+
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	collectEvery(ticker, stop, maxProcessEntries, func(snap *snapshot, err error) bool {
+		if err != nil {
+			log.Printf("wtop: collect: %v", err)
+			return true
+		}
+		push(snap)
+		return true
+	})
+}
+
+// Serve collects snapshots without the terminal UI and hands them to every
+// configured exporter until the process is stopped.
+func Serve(opts ServeOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = refreshInterval
+	}
+
+	var sinks []snapshotSink
+	if opts.PrometheusAddr != "" {
+		exp := newPromExporter(opts.PrometheusTopN)
+		srv, err := exp.listen(opts.PrometheusAddr)
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+		sinks = append(sinks, exp)
+	}
+	if opts.OTLP != nil {
+		exp, err := newOTLPExporter(*opts.OTLP)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, newAsyncSink("otlp", loggedSend("otlp", eachSnapshot(exp.export))))
+	}
+	if opts.Push != nil {
+		push, err := pushSinks(*opts.Push, true)
+		if err != nil {
+			return err
+		}
+		sinks = append(sinks, push...)
+	}
+	if opts.Flight != nil {
+		flight := newFlightRecorder(*opts.Flight)
+		flight.logf = log.Printf
+		sinks = append(sinks, flight)
+	}
+	var history *historySink
+	if opts.HistoryFile != "" {
+		store, err := loadHistory(opts.HistoryFile)
+		if err != nil {
+			return err
+		}
+		history = &historySink{store: store}
+		sinks = append(sinks, history)
+	}
+	if len(sinks) == 0 {
+		return fmt.Errorf("serve needs at least one exporter")
+	}
+
+	collectUntilInterrupted(opts.Interval, func(snap *snapshot) {
+		for _, sink := range sinks {
+			sink.push(snap)
+		}
+	})
+	if history != nil {
+		return history.store.save()
+	}
+	return nil
+}
+
+func loggedSend(name string, send func([]*snapshot) error) func([]*snapshot) error {
+	return func(snaps []*snapshot) error {
+		err := send(snaps)
+		if err != nil {
+			log.Printf("wtop: %s: %v", name, err)
+		}
+		return err
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,41 +19,61 @@ import (
 )
 
 type processSummary struct {
-	Total   int
-	Running int
-	Threads int
+	Total   int `json:"total"`
+	Running int `json:"running"`
+	// Sleeping also counts idle and waiting processes.
+	Sleeping int `json:"sleeping"`
+	Stopped  int `json:"stopped"`
+	Zombie   int `json:"zombie"`
+	Threads  int `json:"threads"`
 }
 
+// snapshot is one round of collected metrics. It is also the JSON document
+// written by the headless modes, so field tags are part of the output format;
+// see snapshotVersion before renaming any of them.
 type snapshot struct {
-	Timestamp time.Time
+	Timestamp time.Time `json:"timestamp"`
 
-	Hostname string
-	Uptime   time.Duration
+	Hostname string        `json:"hostname"`
+	Uptime   time.Duration `json:"-"`
 
-	Load1        float64
-	Load5        float64
-	Load15       float64
-	LoadReported bool
+	Load1        float64 `json:"load1"`
+	Load5        float64 `json:"load5"`
+	Load15       float64 `json:"load15"`
+	LoadReported bool    `json:"loadReported"`
 
-	CPUPerCore []float64
-	TotalCPU   float64
-	CPUFreq    []float64
-	CPUTemp    []float64
+	CPUPerCore []float64                `json:"cpuPerCore"`
+	TotalCPU   float64                  `json:"totalCpu"`
+	CPUFreq    []float64                `json:"cpuFreqMhz"`
+	CPUTemp    []*metrics.SensorReading `json:"cpuTemp,omitempty"`
 
-	Memory *mem.VirtualMemoryStat
-	Swap   *mem.SwapMemoryStat
+	Sensors []*metrics.SensorChip    `json:"sensors,omitempty"`
+	Power   *metrics.PowerSupplyInfo `json:"power,omitempty"`
+	RAPL    []*metrics.RAPLZone      `json:"rapl,omitempty"`
 
-	DiskPath string
-	Disk     *disk.UsageStat
+	Pressure *metrics.PressureStats `json:"pressure,omitempty"`
 
-	GPUInfos     []*metrics.GPUInfo
-	GPUProcesses map[int][]*metrics.GPUProcess
+	Memory       *mem.VirtualMemoryStat `json:"memory,omitempty"`
+	Swap         *mem.SwapMemoryStat    `json:"swap,omitempty"`
+	KernelMemory *metrics.KernelMemory  `json:"kernelMemory,omitempty"`
 
-	ProcessSummary processSummary
-	Processes      []*types.ProcessInfo
+	DiskPath string            `json:"diskPath"`
+	Disk     *disk.UsageStat   `json:"disk,omitempty"`
+	Disks    []*disk.UsageStat `json:"disks"`
+	// DiskIO holds the I/O counters of each block device, by name.
+	DiskIO []disk.IOCountersStat `json:"diskIO,omitempty"`
 
-	NetBytesSent uint64
-	NetBytesRecv uint64
+	GPUInfos     []*metrics.GPUInfo            `json:"gpus"`
+	GPUProcesses map[int][]*metrics.GPUProcess `json:"gpuProcesses,omitempty"`
+
+	ProcessSummary processSummary       `json:"processSummary"`
+	Processes      []*types.ProcessInfo `json:"processes"`
+
+	NetBytesSent uint64 `json:"netBytesSent"`
+	NetBytesRecv uint64 `json:"netBytesRecv"`
+	// Interfaces holds the counters of each network interface that has seen
+	// traffic, by name.
+	Interfaces []gnet.IOCountersStat `json:"interfaces,omitempty"`
 }
 
 func collectSnapshot(limit int) (*snapshot, error) {
@@ -81,17 +102,29 @@ func collectSnapshot(limit int) (*snapshot, error) {
 		}
 	}
 
-	if temps, err := host.SensorsTemperatures(); err == nil {
-		for _, t := range temps {
-			if strings.Contains(strings.ToLower(t.SensorKey), "cpu") || strings.Contains(strings.ToLower(t.SensorKey), "core") {
-				snap.CPUTemp = append(snap.CPUTemp, t.Temperature)
-			}
-		}
+	if chips, err := metrics.GetSensors(); err == nil {
+		snap.Sensors = chips
+		snap.CPUTemp = metrics.CPUTemperatures(chips)
+	}
+
+	if zones, err := metrics.GetRAPLZones(); err == nil {
+		snap.RAPL = zones
+	}
+
+	if power, err := metrics.GetPowerSupply(); err == nil {
+		snap.Power = power
+	}
+
+	if pressure, err := metrics.GetPressure(); err == nil {
+		snap.Pressure = pressure
 	}
 
 	if vm, err := mem.VirtualMemory(); err == nil {
 		snap.Memory = vm
 	}
+	if km, err := metrics.GetKernelMemory(); err == nil {
+		snap.KernelMemory = km
+	}
 	if sw, err := mem.SwapMemory(); err == nil {
 		snap.Swap = sw
 	}
@@ -101,11 +134,14 @@ func collectSnapshot(limit int) (*snapshot, error) {
 	if usage, err := disk.Usage(path); err == nil {
 		snap.Disk = usage
 	}
+	snap.Disks = collectDisks()
+	snap.DiskIO = collectDiskIO()
 
 	if counters, err := gnet.IOCounters(false); err == nil && len(counters) > 0 {
 		snap.NetBytesSent = counters[0].BytesSent
 		snap.NetBytesRecv = counters[0].BytesRecv
 	}
+	snap.Interfaces = collectInterfaces()
 
 	if processes := metrics.GetTopProcesses(limit); len(processes) > 0 {
 		snap.Processes = processes
@@ -113,6 +149,14 @@ func collectSnapshot(limit int) (*snapshot, error) {
 
 	snap.ProcessSummary = collectProcessSummary()
 
+	if activeConfig.GPU {
+		collectGPUs(snap)
+	}
+
+	return snap, nil
+}
+
+func collectGPUs(snap *snapshot) {
 	if gpus, err := metrics.GetGPUInfo(); err == nil {
 		snap.GPUInfos = gpus
 		if len(gpus) > 0 {
@@ -129,8 +173,6 @@ func collectSnapshot(limit int) (*snapshot, error) {
 			}
 		}
 	}
-
-	return snap, nil
 }
 
 func collectProcessSummary() processSummary {
@@ -142,8 +184,15 @@ func collectProcessSummary() processSummary {
 	for _, p := range processes {
 		summary.Total++
 		if status, err := p.Status(); err == nil && len(status) > 0 {
-			if status[0] == "R" {
+			switch status[0] {
+			case process.Running:
 				summary.Running++
+			case process.Stop:
+				summary.Stopped++
+			case process.Zombie:
+				summary.Zombie++
+			default:
+				summary.Sleeping++
 			}
 		}
 		if threads, err := p.NumThreads(); err == nil {
@@ -153,6 +202,63 @@ func collectProcessSummary() processSummary {
 	return summary
 }
 
+func collectDisks() []*disk.UsageStat {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var disks []*disk.UsageStat
+	for _, part := range partitions {
+		if seen[part.Device] {
+			continue
+		}
+		usage, err := disk.Usage(part.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+		seen[part.Device] = true
+		disks = append(disks, usage)
+	}
+	return disks
+}
+
+// collectDiskIO returns the counters of block devices that have done any
+// I/O, leaving out loop and RAM disks.
+func collectDiskIO() []disk.IOCountersStat {
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return nil
+	}
+	var devices []disk.IOCountersStat
+	for name, c := range counters {
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+		if c.ReadCount+c.WriteCount == 0 {
+			continue
+		}
+		devices = append(devices, c)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices
+}
+
+func collectInterfaces() []gnet.IOCountersStat {
+	counters, err := gnet.IOCounters(true)
+	if err != nil {
+		return nil
+	}
+	var active []gnet.IOCountersStat
+	for _, c := range counters {
+		if c.BytesRecv+c.BytesSent > 0 {
+			active = append(active, c)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Name < active[j].Name })
+	return active
+}
+
 func primaryDiskPath() string {
 	if runtime.GOOS == "windows" {
 		return "C:\\"
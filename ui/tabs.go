@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+// tab is a top-level view, switched with the number keys.
+type tab int
+
+const (
+	tabOverview tab = iota
+	tabProcesses
+	tabNetwork
+	tabDisks
+	tabGPU
+	tabSensors
+)
+
+var tabNames = []string{"Overview", "Processes", "Network", "Disks", "GPU", "Sensors"}
+
+func (t tab) String() string { return tabNames[t] }
+
+// tabView returns the primitive filling the screen below the header on tabs
+// other than the overview, which uses the panel layout.
+func (d *Dashboard) tabView(t tab) tview.Primitive {
+	switch t {
+	case tabProcesses:
+		return d.processTable
+	case tabNetwork:
+		return d.networkFlex
+	case tabDisks:
+		return d.diskIOView
+	case tabGPU:
+		return d.gpuFlex
+	case tabSensors:
+		return d.sensorView
+	}
+	return d.mainFlex
+}
+
+// focusView is the primitive the arrow keys scroll on the current tab.
+func (d *Dashboard) focusView() tview.Primitive {
+	switch d.tab {
+	case tabOverview:
+		return d.panelView(d.focused)
+	case tabNetwork:
+		return d.connTable
+	case tabGPU:
+		return d.gpuView
+	}
+	return d.tabView(d.tab)
+}
+
+func (d *Dashboard) switchTab(t tab) {
+	if t == d.tab || d.zoomed != "" {
+		return
+	}
+	d.tab = t
+	d.buildLayout()
+	d.app.SetFocus(d.focusView())
+	d.updateTabBar()
+	if d.lastSnapshot != nil {
+		d.updateTab(d.lastSnapshot)
+	}
+}
+
+func (d *Dashboard) updateTabBar() {
+	parts := make([]string, len(tabNames))
+	for i, name := range tabNames {
+		label := fmt.Sprintf(" %d %s ", i+1, name)
+		if tab(i) == d.tab {
+			label = "[::r]" + label + "[::-]"
+		}
+		parts[i] = label
+	}
+	d.tabBar.SetText(strings.Join(parts, " "))
+}
+
+// updateTab refreshes the views that only the current tab shows.
+func (d *Dashboard) updateTab(snap *snapshot) {
+	switch d.tab {
+	case tabNetwork:
+		d.updateNetwork(snap)
+	case tabDisks:
+		d.updateDiskIO(snap)
+	case tabGPU:
+		d.updateGPUHistory(snap)
+	}
+}
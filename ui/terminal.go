@@ -4,37 +4,43 @@ import (
 	"github.com/gdamore/tcell/v2"
 )
 
+// bindKeys routes key presses through the keymap. Ctrl+C always quits and
+// is not part of it, so a bad rebinding can never lock the user in.
 func (d *Dashboard) bindKeys() {
 	d.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyCtrlC:
-			d.stop()
-			d.app.Stop()
+		if event.Key() == tcell.KeyCtrlC {
+			d.quit()
 			return nil
-		case tcell.KeyF1:
-			d.footer.SetText("Help is coming soon. Visit the README for now.")
+		}
+		if d.pendingAction != nil {
+			d.confirmAction(event.Key() == tcell.KeyRune && (event.Rune() == 'y' || event.Rune() == 'Y'))
 			return nil
-		case tcell.KeyRune:
-			switch event.Rune() {
-			case 'q', 'Q':
-				d.stop()
-				d.app.Stop()
-				return nil
-			case 's', 'S':
-				d.cycleSortMode()
-				return nil
-			case '/':
-				d.footer.SetText("[yellow]Process filtering not implemented yet[-]")
-				return nil
-			}
 		}
-		return event
+		if focus := d.app.GetFocus(); focus == d.filterInput || focus == d.paletteInput {
+			return event
+		}
+		action := d.keymap.lookup(d, event)
+		if d.helpShown {
+			return d.helpKey(action, event)
+		}
+		if action == nil {
+			return event
+		}
+		if action.run == nil {
+			return tcell.NewEventKey(action.key, 0, tcell.ModNone)
+		}
+		action.run(d)
+		return nil
 	})
 
 	d.processTable.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEscape {
-			d.stop()
-			d.app.Stop()
+			d.quit()
 		}
 	})
 }
+
+func (d *Dashboard) quit() {
+	d.stop()
+	d.app.Stop()
+}
@@ -0,0 +1,437 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Theme is the palette the dashboard draws with. Colors are picked by role
+// rather than by hue so that every theme, including user themes from the
+// config file, stays readable on its own background.
+type Theme struct {
+	Background    tcell.Color // panel backgrounds
+	Foreground    tcell.Color // default text
+	Text          tcell.Color // process table cells and neutral readings
+	Muted         tcell.Color // secondary details and empty bar cells
+	Accent        tcell.Color // titles, labels and host names
+	Border        tcell.Color // panel borders and bar brackets
+	StatusBar     tcell.Color // footer and filter prompt background
+	Selection     tcell.Color // selected row background
+	SelectionText tcell.Color // selected row text
+	Good          tcell.Color // usage below the warning threshold
+	Warning       tcell.Color // usage above the warning threshold, notices
+	Critical      tcell.Color // usage above the critical threshold, errors
+}
+
+// themeRoles names the Theme fields for the themes section of the config
+// file.
+var themeRoles = []struct {
+	name  string
+	color func(*Theme) *tcell.Color
+}{
+	{"background", func(t *Theme) *tcell.Color { return &t.Background }},
+	{"foreground", func(t *Theme) *tcell.Color { return &t.Foreground }},
+	{"text", func(t *Theme) *tcell.Color { return &t.Text }},
+	{"muted", func(t *Theme) *tcell.Color { return &t.Muted }},
+	{"accent", func(t *Theme) *tcell.Color { return &t.Accent }},
+	{"border", func(t *Theme) *tcell.Color { return &t.Border }},
+	{"status_bar", func(t *Theme) *tcell.Color { return &t.StatusBar }},
+	{"selection", func(t *Theme) *tcell.Color { return &t.Selection }},
+	{"selection_text", func(t *Theme) *tcell.Color { return &t.SelectionText }},
+	{"good", func(t *Theme) *tcell.Color { return &t.Good }},
+	{"warning", func(t *Theme) *tcell.Color { return &t.Warning }},
+	{"critical", func(t *Theme) *tcell.Color { return &t.Critical }},
+}
+
+var themeNames = []string{"dark", "light", "solarized", "high-contrast", "monochrome"}
+
+var builtinThemes = map[string]Theme{
+	"dark": {
+		Background:    tcell.ColorBlack,
+		Foreground:    tcell.ColorWhite,
+		Text:          tcell.ColorLightGray,
+		Muted:         tcell.ColorGray,
+		Accent:        tcell.ColorLightCyan,
+		Border:        tcell.ColorDarkSlateGray,
+		StatusBar:     tcell.ColorDimGray,
+		Selection:     tcell.ColorLightCyan,
+		SelectionText: tcell.ColorBlack,
+		Good:          tcell.ColorGreen,
+		Warning:       tcell.ColorYellow,
+		Critical:      tcell.ColorIndianRed,
+	},
+	"light": {
+		Background:    tcell.NewHexColor(0xffffff),
+		Foreground:    tcell.NewHexColor(0x000000),
+		Text:          tcell.NewHexColor(0x303030),
+		Muted:         tcell.NewHexColor(0x767676),
+		Accent:        tcell.NewHexColor(0x005f87),
+		Border:        tcell.NewHexColor(0xa8a8a8),
+		StatusBar:     tcell.NewHexColor(0xd0d0d0),
+		Selection:     tcell.NewHexColor(0x005f87),
+		SelectionText: tcell.NewHexColor(0xffffff),
+		Good:          tcell.NewHexColor(0x007a00),
+		Warning:       tcell.NewHexColor(0xa06800),
+		Critical:      tcell.NewHexColor(0xc0272d),
+	},
+	"solarized": {
+		Background:    tcell.NewHexColor(0x002b36),
+		Foreground:    tcell.NewHexColor(0x93a1a1),
+		Text:          tcell.NewHexColor(0x839496),
+		Muted:         tcell.NewHexColor(0x586e75),
+		Accent:        tcell.NewHexColor(0x2aa198),
+		Border:        tcell.NewHexColor(0x586e75),
+		StatusBar:     tcell.NewHexColor(0x073642),
+		Selection:     tcell.NewHexColor(0x268bd2),
+		SelectionText: tcell.NewHexColor(0xfdf6e3),
+		Good:          tcell.NewHexColor(0x859900),
+		Warning:       tcell.NewHexColor(0xb58900),
+		Critical:      tcell.NewHexColor(0xdc322f),
+	},
+	"high-contrast": {
+		Background:    tcell.NewHexColor(0x000000),
+		Foreground:    tcell.NewHexColor(0xffffff),
+		Text:          tcell.NewHexColor(0xffffff),
+		Muted:         tcell.NewHexColor(0xc0c0c0),
+		Accent:        tcell.NewHexColor(0x00ffff),
+		Border:        tcell.NewHexColor(0xffffff),
+		StatusBar:     tcell.NewHexColor(0x000080),
+		Selection:     tcell.NewHexColor(0xffff00),
+		SelectionText: tcell.NewHexColor(0x000000),
+		Good:          tcell.NewHexColor(0x00ff00),
+		Warning:       tcell.NewHexColor(0xffff00),
+		Critical:      tcell.NewHexColor(0xff5555),
+	},
+	// monochrome leaves every color to the terminal; the selection is shown
+	// in reverse video instead.
+	"monochrome": {
+		Background:    tcell.ColorDefault,
+		Foreground:    tcell.ColorDefault,
+		Text:          tcell.ColorDefault,
+		Muted:         tcell.ColorDefault,
+		Accent:        tcell.ColorDefault,
+		Border:        tcell.ColorDefault,
+		StatusBar:     tcell.ColorDefault,
+		Selection:     tcell.ColorDefault,
+		SelectionText: tcell.ColorDefault,
+		Good:          tcell.ColorDefault,
+		Warning:       tcell.ColorDefault,
+		Critical:      tcell.ColorDefault,
+	},
+}
+
+// activeTheme is the palette in use, already fitted to the terminal. It is
+// replaced by UseConfig.
+var activeTheme = fitTheme(builtinThemes["dark"])
+
+// colorDepth is how many colors the terminal shows: 1<<24 for truecolor,
+// 256 or 16. The config's colors setting overrides the guess.
+var colorDepth = detectColorDepth()
+
+func detectColorDepth() int {
+	if os.Getenv("TCELL_TRUECOLOR") != "disable" {
+		switch strings.ToLower(os.Getenv("COLORTERM")) {
+		case "truecolor", "24bit":
+			return 1 << 24
+		}
+		if runtime.GOOS == "windows" && os.Getenv("WT_SESSION") != "" {
+			return 1 << 24
+		}
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return 256
+	}
+	return 16
+}
+
+// noColor reports whether the user asked for no color, see no-color.org.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// useTheme makes t, fitted to the terminal, the active theme and points
+// tview's defaults at it so primitives without explicit colors match.
+func useTheme(t Theme, colors string) {
+	switch colors {
+	case "truecolor":
+		colorDepth = 1 << 24
+	case "256":
+		colorDepth = 256
+	case "16":
+		colorDepth = 16
+	default:
+		colorDepth = detectColorDepth()
+	}
+	if noColor() {
+		t = builtinThemes["monochrome"]
+	}
+	activeTheme = fitTheme(t)
+
+	tview.Styles.PrimitiveBackgroundColor = activeTheme.Background
+	tview.Styles.ContrastBackgroundColor = activeTheme.StatusBar
+	tview.Styles.MoreContrastBackgroundColor = activeTheme.Selection
+	tview.Styles.BorderColor = activeTheme.Border
+	tview.Styles.TitleColor = activeTheme.Accent
+	tview.Styles.GraphicsColor = activeTheme.Border
+	tview.Styles.PrimaryTextColor = activeTheme.Foreground
+	tview.Styles.SecondaryTextColor = activeTheme.Accent
+	tview.Styles.TertiaryTextColor = activeTheme.Good
+	tview.Styles.InverseTextColor = activeTheme.SelectionText
+	tview.Styles.ContrastSecondaryTextColor = activeTheme.Accent
+}
+
+func fitTheme(t Theme) *Theme {
+	for _, role := range themeRoles {
+		c := role.color(&t)
+		*c = fitColor(*c)
+	}
+	return &t
+}
+
+// selectedStyle is the style of the selected table row.
+func (t *Theme) selectedStyle() tcell.Style {
+	if t.Selection == tcell.ColorDefault {
+		return tcell.StyleDefault.Reverse(true).Bold(true)
+	}
+	return tcell.StyleDefault.Foreground(t.SelectionText).Background(t.Selection).Bold(true)
+}
+
+// switchTheme makes the named theme active while the dashboard runs and
+// repaints every view with it.
+func (d *Dashboard) switchTheme(name string) error {
+	cfg := *activeConfig
+	cfg.Theme = name
+	t, problems := cfg.resolveTheme()
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	activeConfig.Theme = name
+	activeConfig.theme = t
+	useTheme(t, activeConfig.Colors)
+
+	for _, tv := range []*tview.TextView{d.header, d.cpuView, d.memoryView, d.diskView, d.gpuView,
+		d.sensorView, d.networkView, d.diskIOView, d.gpuHistView, d.helpView} {
+		tv.SetBorderColor(activeTheme.Border)
+		tv.SetTitleColor(activeTheme.Accent)
+		tv.SetBackgroundColor(activeTheme.Background)
+	}
+	for _, table := range []*tview.Table{d.processTable, d.connTable} {
+		table.SetBackgroundColor(activeTheme.Background)
+		table.SetTitleColor(activeTheme.Accent)
+		table.SetBorderColor(activeTheme.Border)
+		table.SetSelectedStyle(activeTheme.selectedStyle())
+	}
+	for _, input := range []*tview.InputField{d.filterInput, d.paletteInput} {
+		input.SetFieldBackgroundColor(activeTheme.Background)
+		input.SetLabelColor(activeTheme.Accent)
+		input.SetBackgroundColor(activeTheme.StatusBar)
+	}
+	d.footer.SetBackgroundColor(activeTheme.StatusBar)
+	d.tabBar.SetBackgroundColor(activeTheme.StatusBar)
+	d.root.SetBackgroundColor(activeTheme.Background)
+	if d.lastSnapshot != nil {
+		d.updateHeader(d.lastSnapshot, d.lastRates)
+	}
+	d.rerender()
+	return nil
+}
+
+func accentTag() string { return colorTag(activeTheme.Accent) }
+func mutedTag() string  { return colorTag(activeTheme.Muted) }
+func goodTag() string   { return colorTag(activeTheme.Good) }
+func warnTag() string   { return colorTag(activeTheme.Warning) }
+func critTag() string   { return colorTag(activeTheme.Critical) }
+
+// fitColor maps c to the closest color the terminal can show. tcell would
+// otherwise pick the nearest entry of the 16-color palette by distance,
+// which turns most of the softer hues gray; matching by hue keeps warnings
+// yellow and errors red.
+func fitColor(c tcell.Color) tcell.Color {
+	if c == tcell.ColorDefault || !c.Valid() || colorDepth >= 1<<24 {
+		return c
+	}
+	if !c.IsRGB() && int(c-tcell.ColorValid) < colorDepth {
+		return c
+	}
+	r, g, b := c.RGB()
+	if colorDepth >= 256 {
+		return tcell.PaletteColor(xterm256(int(r), int(g), int(b)))
+	}
+	return tcell.PaletteColor(ansi16(int(r), int(g), int(b)))
+}
+
+// cubeLevels are the channel values of the xterm 6x6x6 color cube.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// xterm256 returns the closest entry of the xterm 256-color palette outside
+// the first 16, whose values differ between terminals.
+func xterm256(r, g, b int) int {
+	level := func(v int) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		default:
+			return (v - 35) / 40
+		}
+	}
+	ri, gi, bi := level(r), level(g), level(b)
+	cube := 16 + 36*ri + 6*gi + bi
+	cubeDist := sq(cubeLevels[ri]-r) + sq(cubeLevels[gi]-g) + sq(cubeLevels[bi]-b)
+
+	gray := (r+g+b)/3 - 8
+	gi24 := clampInt((gray+5)/10, 0, 23)
+	gv := 8 + 10*gi24
+	grayDist := sq(gv-r) + sq(gv-g) + sq(gv-b)
+	if grayDist < cubeDist {
+		return 232 + gi24
+	}
+	return cube
+}
+
+// ansi16 picks one of the 16 basic colors by hue and brightness.
+func ansi16(r, g, b int) int {
+	hi, lo := r, r
+	for _, v := range []int{g, b} {
+		if v > hi {
+			hi = v
+		}
+		if v < lo {
+			lo = v
+		}
+	}
+	if hi < 100 || float64(hi-lo)/float64(hi) < 0.1 {
+		switch avg := (r + g + b) / 3; {
+		case avg < 50:
+			return 0
+		case avg < 150:
+			return 8
+		case avg < 220:
+			return 7
+		default:
+			return 15
+		}
+	}
+
+	var hue float64
+	d := float64(hi - lo)
+	switch hi {
+	case r:
+		hue = 60 * float64(g-b) / d
+	case g:
+		hue = 60*float64(b-r)/d + 120
+	default:
+		hue = 60*float64(r-g)/d + 240
+	}
+	if hue < 0 {
+		hue += 360
+	}
+	var base int
+	switch {
+	case hue < 30 || hue >= 330:
+		base = 1 // red
+	case hue < 90:
+		base = 3 // yellow
+	case hue < 150:
+		base = 2 // green
+	case hue < 210:
+		base = 6 // cyan
+	case hue < 270:
+		base = 4 // blue
+	default:
+		base = 5 // magenta
+	}
+	if hi >= 192 {
+		base += 8
+	}
+	return base
+}
+
+func sq(v int) int { return v * v }
+
+// basicColorNames are tview's names for the 16 basic colors, which use the
+// terminal's own palette.
+var basicColorNames = [16]string{
+	"black", "maroon", "green", "olive", "navy", "purple", "teal", "silver",
+	"gray", "red", "lime", "yellow", "blue", "fuchsia", "aqua", "white",
+}
+
+// resolveTheme builds the theme named by c.Theme from the built-in palettes
+// and the themes section.
+func (c *Config) resolveTheme() (Theme, []string) {
+	if t, ok := builtinThemes[c.Theme]; ok {
+		return t, nil
+	}
+	spec, ok := c.Themes[c.Theme]
+	if !ok {
+		names := append([]string(nil), themeNames...)
+		for name := range c.Themes {
+			names = append(names, name)
+		}
+		sort.Strings(names[len(themeNames):])
+		return Theme{}, []string{fmt.Sprintf("theme: unknown theme %q (want one of %s)", c.Theme, strings.Join(names, ", "))}
+	}
+
+	var problems []string
+	base := spec["base"]
+	if base == "" {
+		base = "dark"
+	}
+	t, ok := builtinThemes[base]
+	if !ok {
+		problems = append(problems, fmt.Sprintf("themes.%s.base: unknown theme %q (want one of %s)", c.Theme, base, strings.Join(themeNames, ", ")))
+	}
+	keys := make([]string, 0, len(spec))
+	for key := range spec {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "base" {
+			continue
+		}
+		found := false
+		for _, role := range themeRoles {
+			if role.name != key {
+				continue
+			}
+			found = true
+			color, err := parseColor(spec[key])
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("themes.%s.%s: %v", c.Theme, key, err))
+			}
+			*role.color(&t) = color
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("themes.%s: unknown color %q", c.Theme, key))
+		}
+	}
+	return t, problems
+}
+
+// parseColor accepts "#rrggbb", a W3C color name, a palette index 0-255 or
+// "default" for the terminal's own color.
+func parseColor(s string) (tcell.Color, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "default" {
+		return tcell.ColorDefault, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 255 {
+			return tcell.ColorDefault, fmt.Errorf("palette index %d is outside 0..255", n)
+		}
+		return tcell.PaletteColor(n), nil
+	}
+	if c := tcell.GetColor(s); c != tcell.ColorDefault {
+		return c, nil
+	}
+	return tcell.ColorDefault, fmt.Errorf("%q is not #rrggbb, a color name, 0..255 or default", s)
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed web
+var webAssets embed.FS
+
+const sseHeartbeat = 15 * time.Second
+
+type WebOptions struct {
+	Listen   string
+	Token    string
+	CertFile string
+	KeyFile  string
+	Interval time.Duration
+}
+
+// webServer serves the embedded browser dashboard and streams snapshots to
+// it over server-sent events. Like the agent, each client holds at most one
+// pending snapshot so a slow tablet skips frames instead of lagging behind.
+type webServer struct {
+	token string
+
+	mu      sync.Mutex
+	latest  []byte
+	clients map[chan []byte]struct{}
+}
+
+func newWebServer(token string) *webServer {
+	return &webServer{token: token, clients: make(map[chan []byte]struct{})}
+}
+
+func (s *webServer) push(snap *snapshot) {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest = data
+	for ch := range s.clients {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- data
+	}
+}
+
+func (s *webServer) subscribe() (chan []byte, []byte) {
+	ch := make(chan []byte, 1)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[ch] = struct{}{}
+	return ch, s.latest
+}
+
+func (s *webServer) unsubscribe(ch chan []byte) {
+	s.mu.Lock()
+	delete(s.clients, ch)
+	s.mu.Unlock()
+}
+
+func (s *webServer) handler() http.Handler {
+	assets, _ := fs.Sub(webAssets, "web")
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.Handle("/api/snapshot", s.authorized(http.HandlerFunc(s.serveSnapshot)))
+	mux.Handle("/api/events", s.authorized(http.HandlerFunc(s.serveEvents)))
+	return mux
+}
+
+// authorized checks the token on API requests. Browsers cannot set headers
+// on an EventSource, so the token is also accepted as a query parameter.
+func (s *webServer) authorized(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token := r.URL.Query().Get("token")
+			if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+				token = bearer
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *webServer) serveSnapshot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data := s.latest
+	s.mu.Unlock()
+	if data == nil {
+		http.Error(w, "no snapshot collected yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
+
+func (s *webServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch, latest := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if latest != nil {
+		writeEvent(w, latest)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			writeEvent(w, data)
+		case <-heartbeat.C:
+			// Keeps proxies from closing an idle stream.
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, data []byte) {
+	fmt.Fprintf(w, "event: snapshot\ndata: %s\n\n", data)
+}
+
+// RunWeb serves the browser dashboard on opts.Listen until the process is
+// interrupted.
+func RunWeb(opts WebOptions) error {
+	if opts.Interval <= 0 {
+		opts.Interval = refreshInterval
+	}
+	ln, err := net.Listen("tcp", opts.Listen)
+	if err != nil {
+		return err
+	}
+	scheme := "http"
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		ln = tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+		scheme = "https"
+	}
+	web := newWebServer(opts.Token)
+	srv := &http.Server{Handler: web.handler(), ReadHeaderTimeout: 5 * time.Second}
+	defer srv.Close()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+	if opts.Token == "" {
+		log.Printf("wtop: web: no --token given; anyone who can reach %s can see this machine's processes", ln.Addr())
+	}
+	log.Printf("wtop: web dashboard at %s://%s/", scheme, ln.Addr())
+
+	done := make(chan struct{})
+	go func() {
+		collectUntilInterrupted(opts.Interval, web.push)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case err := <-serveErr:
+		return err
+	}
+}
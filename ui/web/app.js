@@ -0,0 +1,297 @@
+"use strict";
+
+// The page mirrors the terminal dashboard. Snapshots arrive as server-sent
+// events in the same JSON document `wtop --once` prints.
+
+const HISTORY = 90;
+const samples = { cpu: [], mem: [], up: [], down: [] };
+let previous = null;
+let latest = null;
+let sortKey = "cpuPercent";
+let sortAsc = false;
+
+const $ = (id) => document.getElementById(id);
+
+function token() {
+  const params = new URLSearchParams(location.search || location.hash.slice(1));
+  const t = params.get("token");
+  if (t) {
+    localStorage.setItem("wtop-token", t);
+    window.history.replaceState(null, "", location.pathname);
+    return t;
+  }
+  return localStorage.getItem("wtop-token") || "";
+}
+
+// eventsURL carries the token in the query string because EventSource cannot
+// set headers; fetch sends it as an Authorization header instead.
+function eventsURL(path) {
+  const t = token();
+  return t ? `${path}?token=${encodeURIComponent(t)}` : path;
+}
+
+function authHeaders() {
+  const t = token();
+  return t ? { Authorization: `Bearer ${t}` } : {};
+}
+
+function level(percent) {
+  if (percent >= 85) return "crit";
+  if (percent >= 65) return "warn";
+  return "ok";
+}
+
+function formatBytes(value) {
+  const units = ["B", "K", "M", "G", "T", "P"];
+  let i = 0;
+  while (value >= 1024 && i < units.length - 1) {
+    value /= 1024;
+    i++;
+  }
+  return (value >= 100 || i === 0 ? value.toFixed(0) : value.toFixed(1)) + units[i];
+}
+
+function formatUptime(seconds) {
+  const d = Math.floor(seconds / 86400);
+  const h = Math.floor((seconds % 86400) / 3600);
+  const m = Math.floor((seconds % 3600) / 60);
+  return (d > 0 ? `${d}d ` : "") + `${h}h ${m}m`;
+}
+
+function escapeHTML(s) {
+  return String(s ?? "").replace(/[&<>"']/g, (c) => ({
+    "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;",
+  })[c]);
+}
+
+function push(series, value) {
+  series.push(value);
+  if (series.length > HISTORY) series.shift();
+}
+
+function meter(label, percent, detail) {
+  const p = Math.max(0, Math.min(100, percent || 0));
+  return `<div class="meter">
+    <div class="label"><span>${escapeHTML(label)} <b class="${level(p)}">${p.toFixed(1)}%</b></span><span>${escapeHTML(detail || "")}</span></div>
+    <div class="bar"><div class="${level(p)}" style="width:${p}%"></div></div>
+  </div>`;
+}
+
+function sparkline(canvas, seriesList, max) {
+  const ratio = window.devicePixelRatio || 1;
+  const width = canvas.clientWidth;
+  const height = canvas.clientHeight;
+  if (canvas.width !== width * ratio) {
+    canvas.width = width * ratio;
+    canvas.height = height * ratio;
+  }
+  const ctx = canvas.getContext("2d");
+  ctx.setTransform(ratio, 0, 0, ratio, 0, 0);
+  ctx.clearRect(0, 0, width, height);
+  if (max === undefined) {
+    max = Math.max(1, ...seriesList.flatMap((s) => s.values));
+  }
+  for (const { values, color } of seriesList) {
+    if (values.length < 2) continue;
+    const step = width / (HISTORY - 1);
+    const x0 = width - (values.length - 1) * step;
+    ctx.beginPath();
+    values.forEach((v, i) => {
+      const x = x0 + i * step;
+      const y = height - (Math.min(v, max) / max) * (height - 2) - 1;
+      i === 0 ? ctx.moveTo(x, y) : ctx.lineTo(x, y);
+    });
+    ctx.strokeStyle = color;
+    ctx.lineWidth = 1.5;
+    ctx.stroke();
+    ctx.lineTo(width, height);
+    ctx.lineTo(x0, height);
+    ctx.closePath();
+    ctx.globalAlpha = 0.15;
+    ctx.fillStyle = color;
+    ctx.fill();
+    ctx.globalAlpha = 1;
+  }
+}
+
+function css(name) {
+  return getComputedStyle(document.documentElement).getPropertyValue(name).trim();
+}
+
+function renderHeader(snap) {
+  $("host").textContent = snap.hostname || "";
+  document.title = `wtop – ${snap.hostname || ""}`;
+  const parts = [`up ${formatUptime(snap.uptimeSeconds || 0)}`];
+  if (snap.loadReported) {
+    parts.push(`load ${snap.load1.toFixed(2)} ${snap.load5.toFixed(2)} ${snap.load15.toFixed(2)}`);
+  }
+  const ps = snap.processSummary || {};
+  parts.push(`${ps.total || 0} procs, ${ps.running || 0} running, ${ps.threads || 0} threads`);
+  $("summary").textContent = parts.join("  ·  ");
+}
+
+function renderCPU(snap) {
+  const total = snap.totalCpu || 0;
+  const el = $("cpu-total");
+  el.textContent = `${total.toFixed(1)}%`;
+  el.className = `big ${level(total)}`;
+  push(samples.cpu, total);
+  sparkline($("cpu-spark"), [{ values: samples.cpu, color: css("--ok") }], 100);
+  const freqs = snap.cpuFreqMhz || [];
+  $("cpu-cores").innerHTML = (snap.cpuPerCore || []).map((p, i) => {
+    const freq = freqs[i] ? `${(freqs[i] / 1000).toFixed(2)}GHz` : "";
+    return meter(`CPU${i}`, p, freq);
+  }).join("");
+}
+
+function renderMemory(snap) {
+  let html = "";
+  const m = snap.memory;
+  if (m) {
+    html += meter("RAM", m.usedPercent, `${formatBytes(m.used)} / ${formatBytes(m.total)}`);
+    push(samples.mem, m.usedPercent);
+  }
+  const s = snap.swap;
+  if (s && s.total > 0) {
+    html += meter("Swap", s.usedPercent, `${formatBytes(s.used)} / ${formatBytes(s.total)}`);
+  }
+  $("mem-bars").innerHTML = html || "<p class=\"muted\">unavailable</p>";
+  sparkline($("mem-spark"), [{ values: samples.mem, color: css("--warn") }], 100);
+}
+
+function renderNetwork(snap) {
+  if (previous) {
+    const elapsed = (Date.parse(snap.timestamp) - Date.parse(previous.timestamp)) / 1000;
+    const rate = (now, before) => (elapsed > 0 && now >= before ? (now - before) / elapsed : 0);
+    const up = rate(snap.netBytesSent, previous.netBytesSent);
+    const down = rate(snap.netBytesRecv, previous.netBytesRecv);
+    push(samples.up, up);
+    push(samples.down, down);
+    $("net-up").textContent = `${formatBytes(up)}/s`;
+    $("net-down").textContent = `${formatBytes(down)}/s`;
+  }
+  sparkline($("net-spark"), [
+    { values: samples.up, color: css("--up") },
+    { values: samples.down, color: css("--down") },
+  ]);
+}
+
+function renderDisks(snap) {
+  const disks = snap.disks && snap.disks.length ? snap.disks : snap.disk ? [snap.disk] : [];
+  $("disks").innerHTML = disks.map((d) =>
+    meter(d.path, d.usedPercent, `${formatBytes(d.used)} / ${formatBytes(d.total)} ${d.fstype || ""}`)
+  ).join("") || "<p class=\"muted\">no disks</p>";
+}
+
+function renderGPUs(snap) {
+  const gpus = snap.gpus || [];
+  $("gpu-panel").hidden = gpus.length === 0;
+  $("gpus").innerHTML = gpus.map((g) => {
+    const memPercent = g.memoryTotal > 0 ? (g.memoryUsed / g.memoryTotal) * 100 : 0;
+    const facts = [];
+    if (g.temperature) facts.push(`${g.temperature.toFixed(0)}°C`);
+    if (g.powerUsage) facts.push(`${g.powerUsage.toFixed(0)}W${g.powerLimit ? ` / ${g.powerLimit.toFixed(0)}W` : ""}`);
+    if (g.fanSpeed) facts.push(`fan ${g.fanSpeed.toFixed(0)}%`);
+    if (g.clockCore) facts.push(`${g.clockCore} MHz`);
+    const procs = (snap.gpuProcesses || {})[g.index] || [];
+    return `<div class="gpu">
+      <h3>GPU${g.index} ${escapeHTML(g.name)}</h3>
+      ${meter("Util", g.utilization)}
+      ${meter("VRAM", memPercent, `${(g.memoryUsed / 1024).toFixed(1)}G / ${(g.memoryTotal / 1024).toFixed(1)}G`)}
+      <div class="facts">${escapeHTML(facts.join("  ·  "))}${procs.length ? `  ·  ${procs.length} processes` : ""}</div>
+    </div>`;
+  }).join("");
+}
+
+function renderProcesses() {
+  if (!latest) return;
+  const filter = $("filter").value.trim().toLowerCase();
+  let procs = (latest.processes || []).slice();
+  if (filter) {
+    procs = procs.filter((p) =>
+      String(p.pid).includes(filter) ||
+      (p.name || "").toLowerCase().includes(filter) ||
+      (p.command || "").toLowerCase().includes(filter) ||
+      (p.user || "").toLowerCase().includes(filter));
+  }
+  procs.sort((a, b) => {
+    const x = a[sortKey];
+    const y = b[sortKey];
+    const cmp = typeof x === "string" ? String(x).localeCompare(String(y)) : (x || 0) - (y || 0);
+    return sortAsc ? cmp : -cmp;
+  });
+  $("procs").tBodies[0].innerHTML = procs.map((p) => `<tr>
+    <td class="num">${p.pid}</td>
+    <td>${escapeHTML(p.user)}</td>
+    <td class="num ${level(p.cpuPercent)}">${(p.cpuPercent || 0).toFixed(1)}</td>
+    <td class="num">${(p.memPercent || 0).toFixed(1)}</td>
+    <td class="num">${formatBytes(p.resMem || p.memory || 0)}</td>
+    <td class="num">${p.threads || ""}</td>
+    <td>${escapeHTML(p.status)}</td>
+    <td class="cmd" title="${escapeHTML(p.command)}">${escapeHTML(p.command || p.name)}</td>
+  </tr>`).join("");
+  for (const th of $("procs").tHead.rows[0].cells) {
+    th.classList.toggle("sorted", th.dataset.key === sortKey);
+    th.classList.toggle("asc", th.dataset.key === sortKey && sortAsc);
+  }
+}
+
+function render(snap) {
+  latest = snap;
+  renderHeader(snap);
+  renderCPU(snap);
+  renderMemory(snap);
+  renderNetwork(snap);
+  renderDisks(snap);
+  renderGPUs(snap);
+  renderProcesses();
+  previous = snap;
+}
+
+function setStatus(text, live) {
+  const el = $("status");
+  el.textContent = text;
+  el.classList.toggle("live", !!live);
+}
+
+async function checkAuth() {
+  const resp = await fetch("api/snapshot", { headers: authHeaders() });
+  if (resp.status !== 401) return true;
+  const t = prompt("wtop token");
+  if (!t) return false;
+  localStorage.setItem("wtop-token", t);
+  return true;
+}
+
+async function connect() {
+  if (!(await checkAuth().catch(() => true))) {
+    setStatus("token required", false);
+    return;
+  }
+  const events = new EventSource(eventsURL("api/events"));
+  events.addEventListener("snapshot", (e) => {
+    render(JSON.parse(e.data));
+    setStatus(`● live · ${new Date(latest.timestamp).toLocaleTimeString()}`, true);
+  });
+  events.onerror = () => {
+    setStatus("○ reconnecting…", false);
+    if (events.readyState === EventSource.CLOSED) {
+      setTimeout(connect, 3000);
+    }
+  };
+}
+
+$("procs").tHead.addEventListener("click", (e) => {
+  const key = e.target.dataset && e.target.dataset.key;
+  if (!key) return;
+  if (key === sortKey) {
+    sortAsc = !sortAsc;
+  } else {
+    sortKey = key;
+    sortAsc = key === "name" || key === "user" || key === "pid";
+  }
+  renderProcesses();
+});
+$("filter").addEventListener("input", renderProcesses);
+
+connect();
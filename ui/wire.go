@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// protocolVersion is exchanged in the hello/welcome handshake between
+// `wtop connect` and `wtop agent`; peers with different versions refuse to
+// talk instead of misreading each other's frames.
+const protocolVersion = 1
+
+// Frames are a 4-byte big-endian payload length, a kind byte, a flags byte
+// and the JSON payload, gzip-compressed when frameGzip is set.
+const (
+	frameHello byte = iota + 1
+	frameWelcome
+	frameSnapshot
+	frameAction
+	frameResult
+	frameError
+)
+
+const (
+	frameGzip byte = 1 << iota
+)
+
+const (
+	maxFrameSize     = 32 << 20
+	handshakeTimeout = 10 * time.Second
+	writeTimeout     = 10 * time.Second
+
+	// maxHelloSize bounds the one frame the agent reads before checking the
+	// token, so an unauthenticated peer cannot make it allocate much.
+	maxHelloSize = 4 << 10
+)
+
+type helloMsg struct {
+	Version int    `json:"version"`
+	Token   string `json:"token"`
+}
+
+type welcomeMsg struct {
+	Version  int     `json:"version"`
+	Hostname string  `json:"hostname"`
+	Interval float64 `json:"intervalSeconds"`
+	Actions  bool    `json:"actions"`
+}
+
+// actionMsg asks the agent to signal a process. Signal is "TERM" or "KILL".
+type actionMsg struct {
+	ID     uint64 `json:"id"`
+	PID    int32  `json:"pid"`
+	Signal string `json:"signal"`
+}
+
+type resultMsg struct {
+	ID    uint64 `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+type errorMsg struct {
+	Message string `json:"message"`
+}
+
+// frameConn reads and writes frames on a connection. Writes are serialized so
+// snapshots and action results can be sent from different goroutines.
+type frameConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	wmu sync.Mutex
+}
+
+func newFrameConn(conn net.Conn) *frameConn {
+	return &frameConn{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *frameConn) writeFrame(kind byte, v any, compress bool) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var flags byte
+	if compress {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(payload)
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		payload, flags = buf.Bytes(), frameGzip
+	}
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds limit", len(payload))
+	}
+
+	header := make([]byte, 6)
+	binary.BigEndian.PutUint32(header, uint32(len(payload)))
+	header[4], header[5] = kind, flags
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err = c.conn.Write(payload)
+	return err
+}
+
+func (c *frameConn) readFrame() (byte, []byte, error) {
+	return c.readFrameLimit(maxFrameSize, true)
+}
+
+// readHello reads the client's hello, which must be small and uncompressed.
+func (c *frameConn) readHello(hello *helloMsg) error {
+	kind, payload, err := c.readFrameLimit(maxHelloSize, false)
+	if err != nil {
+		return err
+	}
+	if kind != frameHello {
+		return fmt.Errorf("unexpected frame kind %d", kind)
+	}
+	return json.Unmarshal(payload, hello)
+}
+
+// readFrameLimit reads a frame of at most limit bytes, refusing compressed
+// ones unless allowGzip is set.
+func (c *frameConn) readFrameLimit(limit uint32, allowGzip bool) (byte, []byte, error) {
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if size > limit {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", size)
+	}
+	if header[5]&frameGzip != 0 && !allowGzip {
+		return 0, nil, fmt.Errorf("compressed frame not allowed here")
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		if err == io.EOF {
+			// The header promised a payload, so this is not a clean close.
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	if header[5]&frameGzip != 0 {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return 0, nil, err
+		}
+		payload, err = io.ReadAll(io.LimitReader(gz, int64(limit)+1))
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(payload) > int(limit) {
+			return 0, nil, fmt.Errorf("decompressed frame exceeds %d bytes", limit)
+		}
+	}
+	return header[4], payload, nil
+}
+
+// expectFrame reads one frame and decodes it into v, turning an error frame
+// from the peer into a Go error.
+func (c *frameConn) expectFrame(kind byte, v any) error {
+	got, payload, err := c.readFrame()
+	if err != nil {
+		return err
+	}
+	if got == frameError {
+		var msg errorMsg
+		json.Unmarshal(payload, &msg)
+		return fmt.Errorf("agent: %s", msg.Message)
+	}
+	if got != kind {
+		return fmt.Errorf("unexpected frame kind %d", got)
+	}
+	return json.Unmarshal(payload, v)
+}
+
+func (c *frameConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// rawFrame builds a frame by hand so tests can lie about its length.
+func rawFrame(size uint32, kind, flags byte, payload []byte) []byte {
+	header := make([]byte, 6)
+	binary.BigEndian.PutUint32(header, size)
+	header[4], header[5] = kind, flags
+	return append(header, payload...)
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readerConn returns a frameConn reading data, for the decode side only.
+func readerConn(data []byte) *frameConn {
+	return &frameConn{r: bufio.NewReader(bytes.NewReader(data))}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		client, server := net.Pipe()
+		go func() {
+			newFrameConn(client).writeFrame(frameWelcome, welcomeMsg{Version: protocolVersion, Hostname: "box"}, compress)
+			client.Close()
+		}()
+		var welcome welcomeMsg
+		if err := newFrameConn(server).expectFrame(frameWelcome, &welcome); err != nil {
+			t.Fatalf("compress=%v: %v", compress, err)
+		}
+		if welcome.Hostname != "box" || welcome.Version != protocolVersion {
+			t.Errorf("compress=%v: got %+v", compress, welcome)
+		}
+		server.Close()
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	full := rawFrame(10, frameSnapshot, 0, []byte("{}        "))
+	if _, _, err := readerConn(nil).readFrame(); err != io.EOF {
+		t.Errorf("empty stream: err = %v, want EOF", err)
+	}
+	for _, n := range []int{3, 6, 9} {
+		_, _, err := readerConn(full[:n]).readFrame()
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("%d of %d bytes: err = %v", n, len(full), err)
+		}
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	// Only the header is sent: the size must be refused before allocating.
+	_, _, err := readerConn(rawFrame(maxFrameSize+1, frameSnapshot, 0, nil)).readFrame()
+	if err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestReadFrameBadGzip(t *testing.T) {
+	junk := []byte("definitely not gzip")
+	_, _, err := readerConn(rawFrame(uint32(len(junk)), frameSnapshot, frameGzip, junk)).readFrame()
+	if err == nil {
+		t.Error("corrupt gzip payload accepted")
+	}
+
+	// A small compressed frame must not inflate past the limit.
+	bomb := gzipped(t, make([]byte, maxFrameSize+1))
+	_, _, err = readerConn(rawFrame(uint32(len(bomb)), frameSnapshot, frameGzip, bomb)).readFrame()
+	if err == nil || !strings.Contains(err.Error(), "decompressed") {
+		t.Errorf("gzip bomb: err = %v", err)
+	}
+}
+
+func TestReadHello(t *testing.T) {
+	hello := []byte(`{"version":1,"token":"secret"}`)
+	var msg helloMsg
+	if err := readerConn(rawFrame(uint32(len(hello)), frameHello, 0, hello)).readHello(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Token != "secret" || msg.Version != 1 {
+		t.Errorf("hello = %+v", msg)
+	}
+
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"oversized", rawFrame(maxHelloSize+1, frameHello, 0, nil)},
+		{"compressed", rawFrame(uint32(len(gzipped(t, hello))), frameHello, frameGzip, gzipped(t, hello))},
+		{"wrong kind", rawFrame(uint32(len(hello)), frameAction, 0, hello)},
+		{"truncated", rawFrame(uint32(len(hello)), frameHello, 0, hello[:5])},
+		{"not json", rawFrame(3, frameHello, 0, []byte("{{{"))},
+	}
+	for _, tt := range tests {
+		if err := readerConn(tt.frame).readHello(&msg); err == nil {
+			t.Errorf("%s hello accepted", tt.name)
+		}
+	}
+}